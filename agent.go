@@ -0,0 +1,122 @@
+package prompterizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"google.golang.org/genai"
+)
+
+const (
+	defaultMaxToolIterations = 10
+	finalAnswerPrompt        = "Respond with only your final answer as JSON, matching the response schema."
+)
+
+// ToolAgent runs a generator's prompt with tools: it executes the function calls the model returns and
+// sends back their results until the model answers, then decodes the answer into T.
+//
+// Gemini does not combine function calling with a JSON response schema, so tools are offered without the
+// schema. An answer that is not valid JSON for T is followed up with a final request using the schema.
+type ToolAgent[T any] struct {
+	Generator *GeminiGenerator[T]
+	Tools     *ToolSet
+	// Maximum number of rounds of tool calls to execute, defaults to 10
+	MaxIterations int
+}
+
+var _ PromptGenerator[any] = (*ToolAgent[any])(nil)
+
+func NewToolAgent[T any](generator *GeminiGenerator[T], tools *ToolSet) *ToolAgent[T] {
+	return &ToolAgent[T]{
+		Generator: generator,
+		Tools:     tools,
+	}
+}
+
+func (a *ToolAgent[T]) Generate(ctx context.Context) (T, error) {
+	out, _, err := a.Run(ctx)
+	return out, err
+}
+
+// Run returns the decoded answer along with the transcript of the conversation: the prompt, every model
+// turn and the function responses sent back. Tool errors are sent to the model as the function response's
+// "error" so it can recover.
+func (a *ToolAgent[T]) Run(ctx context.Context) (T, []*genai.Content, error) {
+	g := a.Generator
+	contents, config, err := g.prepareRequest()
+	if err != nil {
+		return *new(T), nil, err
+	}
+
+	toolConfig := *config
+	toolConfig.ResponseMIMEType = ""
+	toolConfig.ResponseSchema = nil
+	toolConfig.CandidateCount = 0
+	toolConfig.Tools = append(slices.Clone(config.Tools), a.Tools.Tool())
+
+	maxIterations := a.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = defaultMaxToolIterations
+	}
+
+	for iteration := 1; ; iteration++ {
+		response, err := g.Caller.GenerateContent(ctx, g.Model, contents, &toolConfig)
+		if err != nil {
+			return *new(T), contents, fmt.Errorf("unable to generate content with model %s: %w", g.Model, err)
+		}
+		if response == nil || len(response.Candidates) == 0 || response.Candidates[0].Content == nil {
+			return *new(T), contents, errors.New("prompt response has no candidate at index 0")
+		}
+		contents = append(contents, response.Candidates[0].Content)
+
+		calls := response.FunctionCalls()
+		if len(calls) == 0 {
+			if out, ok := decodeAnswer[T](response, config.ResponseSchema, g.unmarshalOptions()); ok {
+				return out, contents, nil
+			}
+			break
+		}
+		if iteration > maxIterations {
+			return *new(T), contents, fmt.Errorf("model was still calling tools after %d iterations", maxIterations)
+		}
+
+		parts := make([]*genai.Part, len(calls))
+		for i, call := range calls {
+			functionResponse, err := a.Tools.Call(ctx, call)
+			if err != nil {
+				functionResponse = &genai.FunctionResponse{ID: call.ID, Name: call.Name, Response: map[string]any{"error": err.Error()}}
+			}
+			parts[i] = &genai.Part{FunctionResponse: functionResponse}
+		}
+		contents = append(contents, genai.NewContentFromParts(parts, genai.RoleUser))
+	}
+
+	contents = append(contents, genai.NewContentFromText(finalAnswerPrompt, genai.RoleUser))
+	response, err := g.Caller.GenerateContent(ctx, g.Model, contents, config)
+	if err != nil {
+		return *new(T), contents, fmt.Errorf("unable to generate content with model %s: %w", g.Model, err)
+	}
+
+	responseText, err := candidateText(response, 0)
+	if err != nil {
+		return *new(T), contents, err
+	}
+	contents = append(contents, response.Candidates[0].Content)
+
+	out, err := unmarshalValidated[T](responseText, config.ResponseSchema, g.unmarshalOptions())
+	return out, contents, err
+}
+
+// decodeAnswer decodes an answer given without the response schema, if it contains valid JSON for T
+func decodeAnswer[T any](response *genai.GenerateContentResponse, schema *genai.Schema, options UnmarshalOptions) (T, bool) {
+	responseText, err := candidateText(response, 0)
+	if err != nil {
+		return *new(T), false
+	}
+
+	options.Lenient = true
+	out, err := unmarshalValidated[T](responseText, schema, options)
+	return out, err == nil
+}
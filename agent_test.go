@@ -0,0 +1,169 @@
+package prompterizer_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+type OrderSummary struct {
+	OrderID string `json:"orderId" prompt:"orderId,string,required"`
+	Status  string `json:"status" prompt:"status,string,required"`
+}
+
+func callResponse(calls ...*genai.FunctionCall) *genai.GenerateContentResponse {
+	content := &genai.Content{Role: genai.RoleModel}
+	for _, call := range calls {
+		content.Parts = append(content.Parts, &genai.Part{FunctionCall: call})
+	}
+	return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: content, FinishReason: genai.FinishReasonStop}}}
+}
+
+var _ = Describe("ToolAgent", func() {
+	var (
+		model *fakeModel
+		agent *prompterizer.ToolAgent[OrderSummary]
+		calls []string
+	)
+
+	BeforeEach(func() {
+		model = &fakeModel{}
+		calls = nil
+
+		tools := prompterizer.NewToolSet()
+		Expect(prompterizer.RegisterTool(tools, "lookup_order", "Looks up an order", func(ctx context.Context, args LookupOrderArgs) (any, error) {
+			calls = append(calls, args.OrderID)
+			if args.OrderID == "missing" {
+				return nil, errors.New("order not found")
+			}
+			return map[string]string{"status": "shipped"}, nil
+		})).To(Succeed())
+
+		generator := prompterizer.NewGeminiGenerator[OrderSummary](model, "gemini-test", prompterizer.PromptParams{
+			Prompt: []string{"What is the status of order A-1?"},
+		}, prompterizer.PromptSettings{Candidates: 2})
+		agent = prompterizer.NewToolAgent(generator, tools)
+	})
+
+	It("should execute function calls and decode the final answer", func() {
+		model.Responses = []*genai.GenerateContentResponse{
+			callResponse(&genai.FunctionCall{ID: "call-1", Name: "lookup_order", Args: map[string]any{"orderId": "A-1"}}),
+			textResponse("```json\n{\"orderId\": \"A-1\", \"status\": \"shipped\"}\n```"),
+		}
+
+		summary, transcript, err := agent.Run(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary).To(Equal(OrderSummary{OrderID: "A-1", Status: "shipped"}))
+		Expect(calls).To(Equal([]string{"A-1"}))
+
+		Expect(model.Requests).To(HaveLen(2))
+		config := model.Requests[0].Config
+		Expect(config.Tools).To(HaveLen(1))
+		Expect(config.Tools[0].FunctionDeclarations[0].Name).To(Equal("lookup_order"))
+		Expect(config.ResponseSchema).To(BeNil())
+		Expect(config.ResponseMIMEType).To(BeEmpty())
+		Expect(config.CandidateCount).To(BeZero())
+
+		Expect(transcript).To(HaveLen(4))
+		Expect(transcript[0].Parts[0].Text).To(Equal("What is the status of order A-1?"))
+		Expect(transcript[1].Parts[0].FunctionCall.Name).To(Equal("lookup_order"))
+		Expect(transcript[2].Role).To(Equal(genai.RoleUser))
+		Expect(transcript[2].Parts[0].FunctionResponse).To(Equal(&genai.FunctionResponse{
+			ID:       "call-1",
+			Name:     "lookup_order",
+			Response: map[string]any{"output": map[string]string{"status": "shipped"}},
+		}))
+		Expect(model.Requests[1].Contents).To(Equal(transcript[:3]))
+	})
+
+	It("should execute every function call of a response", func() {
+		model.Responses = []*genai.GenerateContentResponse{
+			callResponse(
+				&genai.FunctionCall{Name: "lookup_order", Args: map[string]any{"orderId": "A-1"}},
+				&genai.FunctionCall{Name: "lookup_order", Args: map[string]any{"orderId": "B-2"}},
+			),
+			textResponse(`{"orderId": "A-1", "status": "shipped"}`),
+		}
+
+		_, transcript, err := agent.Run(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls).To(Equal([]string{"A-1", "B-2"}))
+		Expect(transcript[2].Parts).To(HaveLen(2))
+	})
+
+	It("should send tool errors back to the model", func() {
+		model.Responses = []*genai.GenerateContentResponse{
+			callResponse(&genai.FunctionCall{Name: "lookup_order", Args: map[string]any{"orderId": "missing"}}),
+			callResponse(&genai.FunctionCall{Name: "cancel_order", Args: map[string]any{"orderId": "A-1"}}),
+			textResponse(`{"orderId": "missing", "status": "unknown"}`),
+		}
+
+		summary, transcript, err := agent.Run(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Status).To(Equal("unknown"))
+		Expect(transcript[2].Parts[0].FunctionResponse.Response).To(Equal(map[string]any{"error": "tool lookup_order failed: order not found"}))
+		Expect(transcript[4].Parts[0].FunctionResponse.Response).To(Equal(map[string]any{"error": "unknown tool cancel_order"}))
+	})
+
+	It("should ask for a structured answer when the answer is not valid JSON", func() {
+		model.Responses = []*genai.GenerateContentResponse{
+			callResponse(&genai.FunctionCall{Name: "lookup_order", Args: map[string]any{"orderId": "A-1"}}),
+			textResponse("Order A-1 has shipped."),
+			textResponse(`{"orderId": "A-1", "status": "shipped"}`),
+		}
+
+		summary, transcript, err := agent.Run(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Status).To(Equal("shipped"))
+		Expect(model.Requests).To(HaveLen(3))
+
+		final := model.Requests[2]
+		Expect(final.Config.Tools).To(BeEmpty())
+		Expect(final.Config.ResponseMIMEType).To(Equal("application/json"))
+		Expect(final.Config.ResponseSchema.Properties).To(HaveKey("orderId"))
+		Expect(final.Contents[4].Parts[0].Text).To(Equal("Respond with only your final answer as JSON, matching the response schema."))
+		Expect(transcript).To(HaveLen(6))
+	})
+
+	It("should return an error after the maximum number of iterations", func() {
+		agent.MaxIterations = 2
+		call := &genai.FunctionCall{Name: "lookup_order", Args: map[string]any{"orderId": "A-1"}}
+		model.Responses = []*genai.GenerateContentResponse{callResponse(call), callResponse(call), callResponse(call)}
+
+		_, transcript, err := agent.Run(context.Background())
+
+		Expect(err).To(MatchError("model was still calling tools after 2 iterations"))
+		Expect(calls).To(HaveLen(2))
+		Expect(model.Requests).To(HaveLen(3))
+		Expect(transcript).To(HaveLen(6))
+	})
+
+	It("should return an invalid final answer as a validation error", func() {
+		model.Responses = []*genai.GenerateContentResponse{
+			textResponse("I don't know."),
+			textResponse(`{"orderId": "A-1"}`),
+		}
+
+		_, _, err := agent.Run(context.Background())
+
+		var validationErr *prompterizer.ValidationError
+		Expect(errors.As(err, &validationErr)).To(BeTrue())
+		Expect(err).To(MatchError("response does not match schema: $.status: required property is missing"))
+	})
+
+	It("should return model errors", func() {
+		model.Err = errors.New("quota exceeded")
+
+		_, err := agent.Generate(context.Background())
+
+		Expect(err).To(MatchError("unable to generate content with model gemini-test: quota exceeded"))
+	})
+})
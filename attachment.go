@@ -0,0 +1,149 @@
+package prompterizer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+	"google.golang.org/genai"
+)
+
+// Gemini rejects requests above 20MB, so larger files have to be uploaded and referenced by URI
+const defaultMaxInlineBytes = 20 << 20
+
+var mimeTypesByExtension = map[string]string{
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".webp": "image/webp",
+	".heic": "image/heic",
+	".heif": "image/heif",
+	".txt":  "text/plain",
+	".csv":  "text/csv",
+	".html": "text/html",
+	".md":   "text/md",
+	".xml":  "text/xml",
+	".json": "application/json",
+	".wav":  "audio/wav",
+	".mp3":  "audio/mp3",
+	".aiff": "audio/aiff",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".flac": "audio/flac",
+	".mp4":  "video/mp4",
+	".mpeg": "video/mpeg",
+	".mov":  "video/mov",
+	".avi":  "video/avi",
+	".flv":  "video/x-flv",
+	".webm": "video/webm",
+	".wmv":  "video/wmv",
+	".3gp":  "video/3gpp",
+}
+
+type AttachmentPlacement int
+
+const (
+	BeforePrompt AttachmentPlacement = iota
+	AfterPrompt
+)
+
+// Attachment is a document sent alongside the prompt, either as inline text, as bytes, or as a reference
+// to a file uploaded through the Files API or to Cloud Storage. When both Data and URI are set, the data
+// is sent inline unless it is larger than the inline size limit.
+type Attachment struct {
+	Label     string // Shown to the model above the attachment, e.g. "Invoice" or "Contract"
+	Text      string
+	Data      []byte
+	URI       string
+	MimeType  string // Detected from the data or inferred from the URI's extension if not set
+	Placement AttachmentPlacement
+}
+
+// promptAttachments lists the attachments of the params, starting with those set through the single file fields
+func promptAttachments(params PromptParams) []Attachment {
+	var attachments []Attachment
+	if params.FileCategory != "" && params.FileContent != "" {
+		attachments = append(attachments, Attachment{Label: params.FileCategory, Text: params.FileContent})
+	}
+	if params.FileData != nil {
+		attachments = append(attachments, Attachment{Data: params.FileData, MimeType: lo.FromPtr(params.FileMimeType)})
+	}
+	return append(attachments, params.Attachments...)
+}
+
+func attachmentParts(attachment Attachment, index int, format DocumentFormat, maxInlineBytes int) ([]*genai.Part, error) {
+	if maxInlineBytes == 0 {
+		maxInlineBytes = defaultMaxInlineBytes
+	}
+
+	var content *genai.Part
+	switch {
+	case attachment.Text != "" && (attachment.Data != nil || attachment.URI != ""):
+		return nil, errors.New("text cannot be combined with data or a URI")
+
+	case attachment.Text != "":
+		content = genai.NewPartFromText(attachment.Text)
+
+	case attachment.Data != nil && (len(attachment.Data) <= maxInlineBytes || attachment.URI == ""):
+		if len(attachment.Data) > maxInlineBytes {
+			return nil, fmt.Errorf("data is %d bytes, above the inline limit of %d bytes, upload it and set the URI instead", len(attachment.Data), maxInlineBytes)
+		}
+		mimeType := attachment.MimeType
+		if mimeType == "" {
+			var err error
+			if mimeType, err = DetectMimeType(attachment.Data); err != nil {
+				return nil, err
+			}
+		}
+		if err := validateMimeType(mimeType); err != nil {
+			return nil, err
+		}
+		content = genai.NewPartFromBytes(attachment.Data, mimeType)
+
+	case attachment.URI != "":
+		mimeType := attachment.MimeType
+		if mimeType == "" {
+			var err error
+			if mimeType, err = mimeTypeFromURI(attachment.URI); err != nil {
+				return nil, err
+			}
+		}
+		if err := validateMimeType(mimeType); err != nil {
+			return nil, err
+		}
+		content = genai.NewPartFromURI(attachment.URI, mimeType)
+
+	default:
+		return nil, errors.New("one of text, data or a URI must be set")
+	}
+
+	if attachment.Label == "" {
+		return []*genai.Part{content}, nil
+	}
+	if format == nil {
+		format = DashDelimited()
+	}
+	header, text, footer := format(index, attachment)
+	if attachment.Text != "" {
+		content = genai.NewPartFromText(text)
+	}
+	return []*genai.Part{genai.NewPartFromText(header), content, genai.NewPartFromText(footer)}, nil
+}
+
+func mimeTypeFromURI(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid URI %s: %w", uri, err)
+	}
+
+	extension := strings.ToLower(path.Ext(parsed.Path))
+	mimeType, ok := mimeTypesByExtension[extension]
+	if !ok {
+		return "", fmt.Errorf("unable to infer the MIME type of %s, set it explicitly", uri)
+	}
+	return mimeType, nil
+}
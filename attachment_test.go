@@ -0,0 +1,113 @@
+package prompterizer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+var _ = Describe("Attachments", func() {
+	var params prompterizer.PromptParams
+
+	BeforeEach(func() {
+		params = prompterizer.PromptParams{
+			Prompt:         []string{"Compare the invoice against the contract"},
+			ResponseStruct: ResponseStruct{},
+		}
+	})
+
+	It("should emit labeled attachments in order around the prompts", func() {
+		params.Attachments = []prompterizer.Attachment{
+			{Label: "Invoice", Data: []byte("%PDF-invoice"), MimeType: "application/pdf"},
+			{Label: "Contract", Text: "Payment is due within 30 days"},
+			{Text: "Reply in English", Placement: prompterizer.AfterPrompt},
+		}
+
+		_, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts).To(HaveLen(8))
+		Expect(parts[0].Text).To(Equal("--- Invoice\n\n"))
+		Expect(parts[1].InlineData.Data).To(Equal([]byte("%PDF-invoice")))
+		Expect(parts[1].InlineData.MIMEType).To(Equal("application/pdf"))
+		Expect(parts[2].Text).To(Equal("\n\n---\n\n"))
+		Expect(parts[3].Text).To(Equal("--- Contract\n\n"))
+		Expect(parts[4].Text).To(Equal("Payment is due within 30 days"))
+		Expect(parts[5].Text).To(Equal("\n\n---\n\n"))
+		Expect(parts[6].Text).To(Equal("Compare the invoice against the contract"))
+		Expect(parts[7].Text).To(Equal("Reply in English"))
+	})
+
+	It("should emit the single file fields before the attachments", func() {
+		params.FileCategory = "Purchase order"
+		params.FileContent = "PO-1"
+		params.Attachments = []prompterizer.Attachment{{Label: "Invoice", Text: "INV-1"}}
+
+		_, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts).To(HaveLen(7))
+		Expect(parts[0].Text).To(Equal("--- Purchase order\n\n"))
+		Expect(parts[3].Text).To(Equal("--- Invoice\n\n"))
+	})
+
+	It("should detect the MIME type of data", func() {
+		params.Attachments = []prompterizer.Attachment{{Data: []byte("%PDF-1.7\n")}}
+
+		_, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts[0].InlineData.MIMEType).To(Equal("application/pdf"))
+	})
+
+	It("should reference URI attachments with the MIME type inferred from the extension", func() {
+		params.Attachments = []prompterizer.Attachment{
+			{Label: "Recording", URI: "gs://bucket/calls/Call.MP3"},
+			{URI: "https://generativelanguage.googleapis.com/v1beta/files/abc", MimeType: "video/mp4"},
+		}
+
+		_, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts[1].FileData.FileURI).To(Equal("gs://bucket/calls/Call.MP3"))
+		Expect(parts[1].FileData.MIMEType).To(Equal("audio/mp3"))
+		Expect(parts[3].FileData.FileURI).To(Equal("https://generativelanguage.googleapis.com/v1beta/files/abc"))
+		Expect(parts[3].FileData.MIMEType).To(Equal("video/mp4"))
+	})
+
+	It("should send data inline up to the size limit and reference the URI above it", func() {
+		params.MaxInlineBytes = 4
+		params.Attachments = []prompterizer.Attachment{
+			{Data: []byte("1234"), URI: "gs://bucket/small.pdf", MimeType: "application/pdf"},
+			{Data: []byte("12345"), URI: "gs://bucket/large.pdf"},
+		}
+
+		_, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts[0].InlineData.Data).To(Equal([]byte("1234")))
+		Expect(parts[0].FileData).To(BeNil())
+		Expect(parts[1].InlineData).To(BeNil())
+		Expect(parts[1].FileData.FileURI).To(Equal("gs://bucket/large.pdf"))
+		Expect(parts[1].FileData.MIMEType).To(Equal("application/pdf"))
+	})
+
+	DescribeTable("should return an error for invalid attachments",
+		func(attachment prompterizer.Attachment, message string) {
+			params.Attachments = []prompterizer.Attachment{{Text: "valid"}, attachment}
+			params.MaxInlineBytes = 10
+
+			_, _, _, err := prompterizer.GenerateGeminiParts(params)
+
+			Expect(err).To(MatchError("invalid attachment 1: " + message))
+		},
+		Entry("empty", prompterizer.Attachment{Label: "Empty"}, "one of text, data or a URI must be set"),
+		Entry("text and data", prompterizer.Attachment{Text: "a", Data: []byte("b"), MimeType: "text/plain"}, "text cannot be combined with data or a URI"),
+		Entry("text and URI", prompterizer.Attachment{Text: "a", URI: "gs://bucket/a.pdf"}, "text cannot be combined with data or a URI"),
+		Entry("undetectable data", prompterizer.Attachment{Data: []byte{0x00, 0x01, 0x02}}, "unable to detect the MIME type of data, set it explicitly: unsupported MIME type application/octet-stream"),
+		Entry("unsupported MIME type", prompterizer.Attachment{Data: []byte("b"), MimeType: "application/zip"}, "unsupported MIME type application/zip"),
+		Entry("unsupported URI MIME type", prompterizer.Attachment{URI: "gs://bucket/a.bin", MimeType: "application/octet-stream"}, "unsupported MIME type application/octet-stream"),
+		Entry("URI without extension", prompterizer.Attachment{URI: "https://generativelanguage.googleapis.com/v1beta/files/abc"}, "unable to infer the MIME type of https://generativelanguage.googleapis.com/v1beta/files/abc, set it explicitly"),
+		Entry("data above the inline limit", prompterizer.Attachment{Data: make([]byte, 11), MimeType: "application/pdf"}, "data is 11 bytes, above the inline limit of 10 bytes, upload it and set the URI instead"),
+	)
+})
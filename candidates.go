@@ -0,0 +1,107 @@
+package prompterizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+)
+
+type Candidate[T any] struct {
+	Index   int
+	Value   T
+	RawText string
+	Err     error
+}
+
+// SelectionStrategy picks a single value from the candidates generated for a prompt
+type SelectionStrategy[T any] func(candidates []Candidate[T]) (T, error)
+
+// GenerateCandidates unmarshals and validates every candidate of a single response, see
+// PromptSettings.Candidates. Invalid candidates are returned with their error.
+func (g *GeminiGenerator[T]) GenerateCandidates(ctx context.Context) ([]Candidate[T], error) {
+	contents, config, err := g.prepareRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := g.Caller.GenerateContent(ctx, g.Model, contents, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate content with model %s: %w", g.Model, err)
+	}
+	if response == nil || len(response.Candidates) == 0 {
+		return nil, errors.New("prompt response has no candidates")
+	}
+
+	candidates := make([]Candidate[T], len(response.Candidates))
+	for i := range response.Candidates {
+		candidates[i].Index = i
+		candidates[i].RawText, candidates[i].Err = candidateText(response, i)
+		if candidates[i].Err == nil {
+			candidates[i].Value, candidates[i].Err = unmarshalValidated[T](candidates[i].RawText, config.ResponseSchema, g.unmarshalOptions())
+		}
+	}
+	return candidates, nil
+}
+
+func (g *GeminiGenerator[T]) GenerateSelected(ctx context.Context, strategy SelectionStrategy[T]) (T, error) {
+	candidates, err := g.GenerateCandidates(ctx)
+	if err != nil {
+		return *new(T), err
+	}
+	return strategy(candidates)
+}
+
+func FirstValid[T any]() SelectionStrategy[T] {
+	return func(candidates []Candidate[T]) (T, error) {
+		valid, err := validCandidates(candidates)
+		if err != nil {
+			return *new(T), err
+		}
+		return valid[0].Value, nil
+	}
+}
+
+// HighestScore selects the valid candidate with the highest score, preferring earlier candidates on ties
+func HighestScore[T any](score func(T) float64) SelectionStrategy[T] {
+	return func(candidates []Candidate[T]) (T, error) {
+		valid, err := validCandidates(candidates)
+		if err != nil {
+			return *new(T), err
+		}
+
+		best := lo.MaxBy(valid, func(candidate Candidate[T], currentBest Candidate[T]) bool {
+			return score(candidate.Value) > score(currentBest.Value)
+		})
+		return best.Value, nil
+	}
+}
+
+// MajorityVote builds a value from the most common value of each prompt field across the valid
+// candidates, recursing into nested structs. Ties go to the earliest candidate.
+func MajorityVote[T any]() SelectionStrategy[T] {
+	return func(candidates []Candidate[T]) (T, error) {
+		valid, err := validCandidates(candidates)
+		if err != nil {
+			return *new(T), err
+		}
+
+		consensus, err := Merge(lo.Map(valid, func(candidate Candidate[T], _ int) T { return candidate.Value }))
+		if err != nil {
+			return *new(T), err
+		}
+		return consensus.Value, nil
+	}
+}
+
+func validCandidates[T any](candidates []Candidate[T]) ([]Candidate[T], error) {
+	valid := lo.Filter(candidates, func(candidate Candidate[T], _ int) bool { return candidate.Err == nil })
+	if len(valid) == 0 {
+		errs := lo.Map(candidates, func(candidate Candidate[T], _ int) error {
+			return fmt.Errorf("candidate %d: %w", candidate.Index, candidate.Err)
+		})
+		return nil, fmt.Errorf("no valid candidates: %w", errors.Join(errs...))
+	}
+	return valid, nil
+}
@@ -0,0 +1,135 @@
+package prompterizer_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+type Review struct {
+	Sentiment string        `json:"sentiment" prompt:"sentiment,string,required"`
+	Rating    int           `json:"rating" prompt:"rating,integer,required"`
+	Product   ReviewProduct `json:"product" prompt:"product,object"`
+	Note      string        `json:"note"`
+	ReviewSource
+}
+
+type ReviewSource struct {
+	Source string `json:"source" prompt:"source,string"`
+}
+
+type ReviewProduct struct {
+	Name string `json:"name" prompt:"name,string"`
+	SKU  string `json:"sku" prompt:"sku,string"`
+}
+
+var _ = Describe("Candidates", func() {
+	var (
+		model     *fakeModel
+		generator *prompterizer.GeminiGenerator[Review]
+	)
+
+	BeforeEach(func() {
+		model = &fakeModel{}
+		generator = prompterizer.NewGeminiGenerator[Review](model, "gemini-test", prompterizer.PromptParams{
+			Prompt: []string{"Classify the review"},
+		}, prompterizer.PromptSettings{Candidates: 4})
+	})
+
+	Describe("GenerateCandidates", func() {
+		It("should unmarshal every candidate", func() {
+			model.Responses = []*genai.GenerateContentResponse{textResponse(
+				`{"sentiment": "positive", "rating": 5}`,
+				`{"sentiment": "positive"}`,
+				`{"sentiment": "neutral", "rating": 3}`,
+			)}
+
+			candidates, err := generator.GenerateCandidates(context.Background())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(candidates).To(HaveLen(3))
+			Expect(candidates[0].Value.Rating).To(Equal(5))
+			Expect(candidates[1].Index).To(Equal(1))
+			Expect(candidates[1].RawText).To(Equal(`{"sentiment": "positive"}`))
+			Expect(candidates[1].Err).To(MatchError(ContainSubstring("$.rating: required property is missing")))
+			Expect(candidates[2].Value.Sentiment).To(Equal("neutral"))
+			Expect(model.Requests[0].Config.CandidateCount).To(BeNumerically("==", 4))
+		})
+
+		It("should return an error if the response has no candidates", func() {
+			model.Responses = []*genai.GenerateContentResponse{{}}
+
+			_, err := generator.GenerateCandidates(context.Background())
+
+			Expect(err).To(MatchError("prompt response has no candidates"))
+		})
+
+		It("should return an error if the model returns no response", func() {
+			model.Responses = []*genai.GenerateContentResponse{nil}
+
+			_, err := generator.GenerateCandidates(context.Background())
+
+			Expect(err).To(MatchError("prompt response has no candidates"))
+		})
+	})
+
+	Describe("selection strategies", func() {
+		candidates := []prompterizer.Candidate[Review]{
+			{Index: 0, Err: context.DeadlineExceeded},
+			{Index: 1, Value: Review{Sentiment: "positive", Rating: 4, Product: ReviewProduct{Name: "Widget", SKU: "W-1"}, Note: "first valid"}},
+			{Index: 2, Value: Review{Sentiment: "negative", Rating: 5, Product: ReviewProduct{Name: "Widget", SKU: "W-2"}, ReviewSource: ReviewSource{Source: "b"}}},
+			{Index: 3, Value: Review{Sentiment: "positive", Rating: 5, Product: ReviewProduct{Name: "Gadget", SKU: "W-2"}, ReviewSource: ReviewSource{Source: "b"}}},
+		}
+
+		It("should select the first valid candidate", func() {
+			review, err := prompterizer.FirstValid[Review]()(candidates)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(review.Note).To(Equal("first valid"))
+		})
+
+		It("should select the candidate with the highest score", func() {
+			review, err := prompterizer.HighestScore(func(review Review) float64 { return float64(review.Rating) })(candidates)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(review.Sentiment).To(Equal("negative"))
+		})
+
+		It("should select the majority value of each field", func() {
+			review, err := prompterizer.MajorityVote[Review]()(candidates)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(review).To(Equal(Review{
+				Sentiment:    "positive",
+				Rating:       5,
+				Product:      ReviewProduct{Name: "Widget", SKU: "W-2"},
+				Note:         "first valid",
+				ReviewSource: ReviewSource{Source: "b"},
+			}))
+		})
+
+		It("should select from candidates generated by the model", func() {
+			model.Responses = []*genai.GenerateContentResponse{textResponse(
+				`{"sentiment": "positive", "rating": 5}`,
+				`{"sentiment": "negative", "rating": 4}`,
+				`{"sentiment": "negative", "rating": 5}`,
+			)}
+
+			review, err := generator.GenerateSelected(context.Background(), prompterizer.MajorityVote[Review]())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(review.Sentiment).To(Equal("negative"))
+			Expect(review.Rating).To(Equal(5))
+		})
+
+		It("should return an error if no candidate is valid", func() {
+			_, err := prompterizer.MajorityVote[Review]()(candidates[:1])
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no valid candidates: candidate 0: context deadline exceeded"))
+		})
+	})
+})
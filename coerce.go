@@ -0,0 +1,102 @@
+package prompterizer
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+type Coercion struct {
+	Path string
+	From string // The original value as JSON
+	To   genai.Type
+}
+
+func (n *normalizer) coerceValue(schema *genai.Schema, value any, path string) any {
+	coerced, ok := coerceToType(schema.Type, value)
+	if !ok {
+		return value
+	}
+
+	from, _ := json.Marshal(value)
+	n.report.Coercions = append(n.report.Coercions, Coercion{Path: path, From: string(from), To: schema.Type})
+	return coerced
+}
+
+// coerceToType converts a value to the given type when it can be done without losing information,
+// returning false if the value already has that type or cannot be converted.
+func coerceToType(schemaType genai.Type, value any) (any, bool) {
+	switch schemaType {
+	case genai.TypeInteger:
+		switch v := value.(type) {
+		case string:
+			return parseLooseInteger(v)
+		case json.Number:
+			if _, err := v.Int64(); err == nil {
+				return nil, false
+			}
+			return parseLooseInteger(v.String())
+		}
+
+	case genai.TypeNumber:
+		if v, ok := value.(string); ok {
+			return parseLooseNumber(v)
+		}
+
+	case genai.TypeBoolean:
+		if v, ok := value.(string); ok {
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "yes":
+				return true, true
+			case "false", "no":
+				return false, true
+			}
+		}
+
+	case genai.TypeString:
+		switch v := value.(type) {
+		case json.Number:
+			return v.String(), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+	}
+
+	return nil, false
+}
+
+var (
+	jsonNumberRegexp    = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+	groupedNumberRegexp = regexp.MustCompile(`^-?[1-9][0-9]{0,2}(,[0-9]{3})+(\.[0-9]+)?$`)
+)
+
+// parseLooseNumber accepts decimal literals, optionally with a leading '+' or thousands separators. Commas that
+// do not group thousands (e.g. "1,5") are ambiguous, so the value is left as is.
+func parseLooseNumber(value string) (any, bool) {
+	cleaned := strings.TrimPrefix(strings.TrimSpace(value), "+")
+	if groupedNumberRegexp.MatchString(cleaned) {
+		cleaned = strings.ReplaceAll(cleaned, ",", "")
+	}
+	if !jsonNumberRegexp.MatchString(cleaned) {
+		return nil, false
+	}
+	// Keep the textual form so decimal types don't lose precision
+	return json.Number(cleaned), true
+}
+
+func parseLooseInteger(value string) (any, bool) {
+	number, ok := parseLooseNumber(value)
+	if !ok {
+		return nil, false
+	}
+
+	parsed, err := strconv.ParseFloat(string(number.(json.Number)), 64)
+	if err != nil || parsed != math.Trunc(parsed) || math.Abs(parsed) > 1<<53 {
+		return nil, false
+	}
+	return json.Number(strconv.FormatInt(int64(parsed), 10)), true
+}
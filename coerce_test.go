@@ -0,0 +1,120 @@
+package prompterizer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shopspring/decimal"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+type LooselyTyped struct {
+	Count    int               `json:"count" prompt:"count,integer"`
+	Ratio    *float64          `json:"ratio" prompt:"ratio,number"`
+	Total    decimal.Decimal   `json:"total" prompt:"total,number"`
+	Approved bool              `json:"approved" prompt:"approved,bool"`
+	Zip      string            `json:"zip" prompt:"zip,string"`
+	Status   string            `json:"status" prompt:"status,string" prompt_enum:"{statuses}"`
+	Scores   []int             `json:"scores" prompt:"scores,integer"`
+	Limits   map[string]int    `json:"limits" prompt:"limits,integer"`
+	Lines    []LooselyTypedRow `json:"lines" prompt:"lines,object"`
+}
+
+type LooselyTypedRow struct {
+	Quantity int `json:"quantity" prompt:"quantity,integer" prompt_aliases:"qty"`
+}
+
+var _ = Describe("Coercion", func() {
+	var options prompterizer.UnmarshalOptions
+
+	BeforeEach(func() {
+		options = prompterizer.UnmarshalOptions{
+			Coerce:            true,
+			TemplateVariables: map[string]string{"statuses": "open,closed"},
+		}
+	})
+
+	It("should coerce loosely-typed values and report each coercion", func() {
+		value, report, err := prompterizer.UnmarshalWithOptions[LooselyTyped](`{
+			"count": "42",
+			"ratio": "0.75",
+			"total": "1,234.50",
+			"approved": "true",
+			"zip": 12345,
+			"status": "open",
+			"scores": ["1", 2, "3.0"],
+			"limits": [{"key": "daily", "value": "10"}],
+			"lines": [{"qty": " 7 "}]
+		}`, options)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value.Count).To(Equal(42))
+		Expect(*value.Ratio).To(Equal(0.75))
+		Expect(value.Total.String()).To(Equal("1234.5"))
+		Expect(value.Approved).To(BeTrue())
+		Expect(value.Zip).To(Equal("12345"))
+		Expect(value.Scores).To(Equal([]int{1, 2, 3}))
+		Expect(value.Limits).To(HaveKeyWithValue("daily", 10))
+		Expect(value.Lines[0].Quantity).To(Equal(7))
+
+		Expect(report.Coercions).To(ConsistOf(
+			prompterizer.Coercion{Path: "$.count", From: `"42"`, To: genai.TypeInteger},
+			prompterizer.Coercion{Path: "$.ratio", From: `"0.75"`, To: genai.TypeNumber},
+			prompterizer.Coercion{Path: "$.total", From: `"1,234.50"`, To: genai.TypeNumber},
+			prompterizer.Coercion{Path: "$.approved", From: `"true"`, To: genai.TypeBoolean},
+			prompterizer.Coercion{Path: "$.zip", From: `12345`, To: genai.TypeString},
+			prompterizer.Coercion{Path: "$.scores[0]", From: `"1"`, To: genai.TypeInteger},
+			prompterizer.Coercion{Path: "$.scores[2]", From: `"3.0"`, To: genai.TypeInteger},
+			prompterizer.Coercion{Path: "$.limits.daily", From: `"10"`, To: genai.TypeInteger},
+			prompterizer.Coercion{Path: "$.lines[0].quantity", From: `" 7 "`, To: genai.TypeInteger},
+		))
+	})
+
+	It("should coerce yes and no to booleans", func() {
+		value, _, err := prompterizer.UnmarshalWithOptions[LooselyTyped](`{"approved": "Yes"}`, options)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value.Approved).To(BeTrue())
+	})
+
+	It("should leave values that cannot be coerced safely", func() {
+		_, report, err := prompterizer.UnmarshalWithOptions[LooselyTyped](`{"count": "4.5"}`, options)
+
+		Expect(err).To(HaveOccurred())
+		Expect(report.Coercions).To(BeEmpty())
+
+		_, _, err = prompterizer.UnmarshalWithOptions[LooselyTyped](`{"approved": "maybe"}`, options)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("should not coerce ambiguous or non-decimal numbers",
+		func(ratio string) {
+			_, report, err := prompterizer.UnmarshalWithOptions[LooselyTyped](`{"ratio": "`+ratio+`"}`, options)
+
+			Expect(err).To(HaveOccurred())
+			Expect(report.Coercions).To(BeEmpty())
+		},
+		Entry("decimal comma", "1,5"),
+		Entry("misplaced separators", "12,34"),
+		Entry("not a number", "NaN"),
+		Entry("infinity", "Inf"),
+		Entry("hexadecimal float", "0x1p3"),
+		Entry("trailing dot", "5."),
+	)
+
+	It("should not coerce values by default", func() {
+		_, _, err := prompterizer.UnmarshalWithOptions[LooselyTyped](`{"count": "42"}`, prompterizer.UnmarshalOptions{})
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error if the schema cannot be generated", func() {
+		options.TemplateVariables = nil
+
+		_, _, err := prompterizer.UnmarshalWithOptions[LooselyTyped](`{"count": "42"}`, options)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to generate schema for coercion: error rendering enum for status: missing variable in enum: statuses"))
+	})
+})
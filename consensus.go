@@ -0,0 +1,201 @@
+package prompterizer
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/samber/lo"
+)
+
+type Consensus[T any] struct {
+	Value T
+	// Fraction of the merged values that agree with the chosen value, keyed by JSON path, e.g. $.lineItems[0].amount
+	Agreement map[string]float64
+}
+
+type merger struct {
+	agreement map[string]float64
+}
+
+// Merge builds a consensus value from several values decoded from candidates or separate runs. Each
+// prompt field is set to its most common value, recursing into nested structs, into maps key by key and
+// into slices whose lengths agree, and the share of values agreeing with it is recorded. Ties go to the
+// earliest value.
+func Merge[T any](values []T) (*Consensus[T], error) {
+	if len(values) == 0 {
+		return nil, errors.New("at least one value is required to merge")
+	}
+
+	m := &merger{agreement: map[string]float64{}}
+	reflectValues := lo.Map(values, func(value T, i int) reflect.Value { return reflect.ValueOf(&values[i]).Elem() })
+	merged := m.merge(reflect.TypeFor[T](), reflectValues, "$")
+
+	return &Consensus[T]{
+		Value:     merged.Interface().(T),
+		Agreement: m.agreement,
+	}, nil
+}
+
+// LowConfidence returns the sorted paths of the fields whose agreement is below the threshold
+func (c *Consensus[T]) LowConfidence(threshold float64) []string {
+	paths := lo.Keys(lo.PickBy(c.Agreement, func(_ string, agreement float64) bool { return agreement < threshold }))
+	slices.Sort(paths)
+	return paths
+}
+
+func (m *merger) merge(currentType reflect.Type, values []reflect.Value, path string) reflect.Value {
+	switch {
+	case currentType.Kind() == reflect.Struct && !implementsUnmarshaler(currentType):
+		return m.mergeStruct(currentType, values, path)
+
+	case currentType.Kind() == reflect.Pointer && lo.EveryBy(values, func(value reflect.Value) bool { return !value.IsNil() }):
+		elements := lo.Map(values, func(value reflect.Value, _ int) reflect.Value { return value.Elem() })
+		result := reflect.New(currentType.Elem())
+		result.Elem().Set(m.merge(currentType.Elem(), elements, path))
+		return result
+
+	case (currentType.Kind() == reflect.Slice || currentType.Kind() == reflect.Array) && values[0].Len() > 0 && sameLength(values):
+		return m.mergeItems(currentType, values, path)
+
+	case currentType.Kind() == reflect.Map && currentType.Key().Kind() == reflect.String &&
+		lo.SomeBy(values, func(value reflect.Value) bool { return value.Len() > 0 }):
+		return m.mergeEntries(currentType, values, path)
+	}
+
+	value, count := mostCommonValue(values)
+	m.agreement[path] = float64(count) / float64(len(values))
+	return value
+}
+
+func (m *merger) mergeStruct(currentType reflect.Type, values []reflect.Value, path string) reflect.Value {
+	// Fields without a prompt tag are taken from the first value
+	result := reflect.New(currentType).Elem()
+	result.Set(values[0])
+	detachEmbedded(result)
+
+	for _, field := range promptFields(currentType) {
+		fieldValues := lo.Map(values, func(value reflect.Value, _ int) reflect.Value {
+			return fieldByIndex(value, field.Field.Index)
+		})
+		merged := m.merge(field.Field.Type, fieldValues, path+"."+field.Params.Name)
+		allocFieldByIndex(result, field.Field.Index).Set(merged)
+	}
+	return result
+}
+
+func (m *merger) mergeItems(currentType reflect.Type, values []reflect.Value, path string) reflect.Value {
+	length := values[0].Len()
+	result := reflect.New(currentType).Elem()
+	if currentType.Kind() == reflect.Slice {
+		result.Set(reflect.MakeSlice(currentType, length, length))
+	}
+	for i := 0; i < length; i++ {
+		items := lo.Map(values, func(value reflect.Value, _ int) reflect.Value { return value.Index(i) })
+		result.Index(i).Set(m.merge(currentType.Elem(), items, fmt.Sprintf("%s[%d]", path, i)))
+	}
+	return result
+}
+
+// mergeEntries merges map values key by key. A key missing from some of the maps is kept if most of them
+// have it, and its agreement counts the maps without it as disagreeing.
+func (m *merger) mergeEntries(currentType reflect.Type, values []reflect.Value, path string) reflect.Value {
+	keys := lo.Uniq(lo.FlatMap(values, func(value reflect.Value, _ int) []string {
+		return lo.Map(value.MapKeys(), func(key reflect.Value, _ int) string { return key.String() })
+	}))
+	slices.Sort(keys)
+
+	result := reflect.MakeMapWithSize(currentType, len(keys))
+	for _, key := range keys {
+		keyPath := path + "." + key
+		mapKey := reflect.ValueOf(key).Convert(currentType.Key())
+		present := lo.Filter(values, func(value reflect.Value, _ int) bool { return value.MapIndex(mapKey).IsValid() })
+		items := lo.Map(present, func(value reflect.Value, _ int) reflect.Value { return value.MapIndex(mapKey) })
+
+		if len(present) == len(values) {
+			result.SetMapIndex(mapKey, m.merge(currentType.Elem(), items, keyPath))
+			continue
+		}
+		if absent := len(values) - len(present); absent > len(present) || (absent == len(present) && !values[0].MapIndex(mapKey).IsValid()) {
+			m.agreement[keyPath] = float64(absent) / float64(len(values))
+			continue
+		}
+		value, count := mostCommonValue(items)
+		m.agreement[keyPath] = float64(count) / float64(len(values))
+		result.SetMapIndex(mapKey, value)
+	}
+	return result
+}
+
+func sameLength(values []reflect.Value) bool {
+	return lo.EveryBy(values, func(value reflect.Value) bool { return value.Len() == values[0].Len() })
+}
+
+// mostCommonValue returns the most common value and its count, counted against its first occurrence
+// so ties go to the earliest
+func mostCommonValue(values []reflect.Value) (reflect.Value, int) {
+	counts := make([]int, len(values))
+	for i, value := range values {
+		for j := 0; j <= i; j++ {
+			if reflect.DeepEqual(values[j].Interface(), value.Interface()) {
+				counts[j]++
+				break
+			}
+		}
+	}
+
+	best := 0
+	for i, count := range counts {
+		if count > counts[best] {
+			best = i
+		}
+	}
+	return values[best], counts[best]
+}
+
+// fieldByIndex returns the zero value if the path goes through a nil embedded pointer
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	field, err := value.FieldByIndexErr(index)
+	if err != nil {
+		return reflect.Zero(value.Type().FieldByIndex(index).Type)
+	}
+	return field
+}
+
+// detachEmbedded replaces embedded struct pointers with copies, so that setting promoted fields doesn't write
+// into the value they were copied from
+func detachEmbedded(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if !value.Type().Field(i).Anonymous || !field.CanSet() {
+			continue
+		}
+		if field.Kind() == reflect.Pointer && !field.IsNil() && field.Type().Elem().Kind() == reflect.Struct {
+			detached := reflect.New(field.Type().Elem())
+			detached.Elem().Set(field.Elem())
+			field.Set(detached)
+			field = detached
+		}
+		if field.Kind() == reflect.Pointer {
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Struct {
+			detachEmbedded(field)
+		}
+	}
+}
+
+// allocFieldByIndex allocates nil embedded pointers along the path so the field can be set
+func allocFieldByIndex(value reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(fieldIndex)
+	}
+	return value
+}
@@ -0,0 +1,130 @@
+package prompterizer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+type Receipt struct {
+	Merchant string        `json:"merchant" prompt:"merchant,string"`
+	Total    *float64      `json:"total" prompt:"total,number"`
+	Items    []ReceiptItem `json:"items" prompt:"items,object"`
+	Tags     []string      `json:"tags" prompt:"tags,string"`
+	Note     string        `json:"note"`
+}
+
+type ReceiptItem struct {
+	Name   string `json:"name" prompt:"name,string"`
+	Amount int    `json:"amount" prompt:"amount,integer"`
+}
+
+type TaggedReceipt struct {
+	Attrs map[string]int `json:"attrs" prompt:"attrs,integer"`
+}
+
+type ReceiptStore struct {
+	Store string `json:"store" prompt:"store,string"`
+}
+
+type BrandedReceipt struct {
+	*ReceiptStore
+	Merchant string `json:"merchant" prompt:"merchant,string"`
+}
+
+var _ = Describe("Merge", func() {
+	total := func(value float64) *float64 { return &value }
+
+	It("should take the most common value of each field and score its agreement", func() {
+		consensus, err := prompterizer.Merge([]Receipt{
+			{Merchant: "ACME", Total: total(12.5), Items: []ReceiptItem{{Name: "Nails", Amount: 10}, {Name: "Glue", Amount: 2}}, Note: "first"},
+			{Merchant: "ACME", Total: total(12.5), Items: []ReceiptItem{{Name: "Nails", Amount: 12}, {Name: "Glue", Amount: 2}}, Note: "second"},
+			{Merchant: "Acme Inc", Total: total(21.5), Items: []ReceiptItem{{Name: "Nails", Amount: 10}, {Name: "Tape", Amount: 2}}},
+			{Merchant: "ACME", Total: total(12.5), Items: []ReceiptItem{{Name: "Nails", Amount: 12}, {Name: "Glue", Amount: 2}}},
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consensus.Value.Merchant).To(Equal("ACME"))
+		Expect(*consensus.Value.Total).To(Equal(12.5))
+		Expect(consensus.Value.Items).To(Equal([]ReceiptItem{{Name: "Nails", Amount: 10}, {Name: "Glue", Amount: 2}}))
+		Expect(consensus.Value.Note).To(Equal("first"))
+		Expect(consensus.Agreement).To(Equal(map[string]float64{
+			"$.merchant":        0.75,
+			"$.total":           0.75,
+			"$.items[0].name":   1,
+			"$.items[0].amount": 0.5,
+			"$.items[1].name":   0.75,
+			"$.items[1].amount": 1,
+			"$.tags":            1,
+		}))
+	})
+
+	It("should score slices of different lengths as a whole", func() {
+		consensus, err := prompterizer.Merge([]Receipt{
+			{Tags: []string{"office"}},
+			{Tags: []string{"office", "tools"}},
+			{Tags: []string{"office", "tools"}},
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consensus.Value.Tags).To(Equal([]string{"office", "tools"}))
+		Expect(consensus.Agreement).To(HaveKeyWithValue("$.tags", BeNumerically("~", 2.0/3, 0.0001)))
+		Expect(consensus.Agreement).ToNot(HaveKey("$.tags[0]"))
+	})
+
+	It("should vote on nil and non-nil pointers as values", func() {
+		consensus, err := prompterizer.Merge([]Receipt{{Total: nil}, {Total: total(3)}, {Total: nil}})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consensus.Value.Total).To(BeNil())
+		Expect(consensus.Agreement).To(HaveKeyWithValue("$.total", BeNumerically("~", 2.0/3, 0.0001)))
+	})
+
+	It("should list the fields below the agreement threshold", func() {
+		consensus, err := prompterizer.Merge([]Receipt{
+			{Merchant: "ACME", Total: total(1)},
+			{Merchant: "ACME", Total: total(2)},
+			{Merchant: "Acme", Total: total(3)},
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consensus.LowConfidence(0.7)).To(Equal([]string{"$.merchant", "$.total"}))
+		Expect(consensus.LowConfidence(0.5)).To(Equal([]string{"$.total"}))
+	})
+
+	It("should merge maps key by key", func() {
+		consensus, err := prompterizer.Merge([]TaggedReceipt{
+			{Attrs: map[string]int{"a": 1, "b": 1, "c": 1}},
+			{Attrs: map[string]int{"a": 1, "b": 2}},
+			{Attrs: map[string]int{"a": 1, "b": 3}},
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consensus.Value.Attrs).To(Equal(map[string]int{"a": 1, "b": 1}))
+		Expect(consensus.Agreement).To(HaveKeyWithValue("$.attrs.a", 1.0))
+		Expect(consensus.Agreement).To(HaveKeyWithValue("$.attrs.b", BeNumerically("~", 1.0/3, 0.0001)))
+		Expect(consensus.Agreement).To(HaveKeyWithValue("$.attrs.c", BeNumerically("~", 2.0/3, 0.0001)))
+		Expect(consensus.Agreement).ToNot(HaveKey("$.attrs"))
+	})
+
+	It("should not modify the values through embedded pointers", func() {
+		values := []BrandedReceipt{
+			{ReceiptStore: &ReceiptStore{Store: "a"}},
+			{ReceiptStore: &ReceiptStore{Store: "b"}},
+			{ReceiptStore: &ReceiptStore{Store: "b"}},
+		}
+
+		consensus, err := prompterizer.Merge(values)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consensus.Value.Store).To(Equal("b"))
+		Expect(values[0].Store).To(Equal("a"))
+		Expect(values[1].Store).To(Equal("b"))
+	})
+
+	It("should return an error without values", func() {
+		_, err := prompterizer.Merge([]Receipt{})
+
+		Expect(err).To(MatchError("at least one value is required to merge"))
+	})
+})
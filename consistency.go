@@ -0,0 +1,79 @@
+package prompterizer
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type TagMismatch struct {
+	Path       string // Go field path, e.g. Invoice.LineItems.Amount
+	JSONName   string // Empty if the field is excluded from json
+	PromptName string
+}
+
+type TagMismatchError struct {
+	Mismatches []TagMismatch
+}
+
+func (e *TagMismatchError) Error() string {
+	mismatches := lo.Map(e.Mismatches, func(mismatch TagMismatch, _ int) string {
+		if mismatch.JSONName == "" {
+			return fmt.Sprintf("%s: prompt name '%s' is excluded from json", mismatch.Path, mismatch.PromptName)
+		}
+		return fmt.Sprintf("%s: json name '%s' does not match prompt name '%s'", mismatch.Path, mismatch.JSONName, mismatch.PromptName)
+	})
+	return fmt.Sprintf("json and prompt tags are inconsistent: %s", strings.Join(mismatches, "; "))
+}
+
+// CheckTagConsistency reports prompt tagged fields that Unmarshal cannot populate from the model's
+// response because their json name differs from their prompt name. encoding/json matches names
+// case-insensitively, so only differences beyond case are reported.
+func CheckTagConsistency(v any) error {
+	if v == nil {
+		return errors.New("input value for tag consistency check cannot be nil")
+	}
+
+	vType := reflect.TypeOf(v)
+	var mismatches []TagMismatch
+	collectTagMismatches(vType, indirectTypeName(vType), map[reflect.Type]bool{}, &mismatches)
+
+	if len(mismatches) > 0 {
+		return &TagMismatchError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+func collectTagMismatches(currentType reflect.Type, path string, visited map[reflect.Type]bool, mismatches *[]TagMismatch) {
+	for lo.Contains([]reflect.Kind{reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map}, currentType.Kind()) {
+		currentType = currentType.Elem()
+	}
+	if currentType.Kind() != reflect.Struct || visited[currentType] {
+		return
+	}
+	visited[currentType] = true
+
+	for _, field := range promptFields(currentType) {
+		fieldPath := path + "." + field.Field.Name
+
+		jsonName, ok := jsonFieldName(field.Field)
+		switch {
+		case !ok:
+			*mismatches = append(*mismatches, TagMismatch{Path: fieldPath, PromptName: field.Params.Name})
+		case !strings.EqualFold(jsonName, field.Params.Name):
+			*mismatches = append(*mismatches, TagMismatch{Path: fieldPath, JSONName: jsonName, PromptName: field.Params.Name})
+		}
+
+		collectTagMismatches(field.Field.Type, fieldPath, visited, mismatches)
+	}
+}
+
+func indirectTypeName(t reflect.Type) string {
+	for lo.Contains([]reflect.Kind{reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map}, t.Kind()) {
+		t = t.Elem()
+	}
+	return t.Name()
+}
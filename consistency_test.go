@@ -0,0 +1,64 @@
+package prompterizer_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+type Mislabeled struct {
+	Name     string           `json:"fullName" prompt:"name,string"`
+	Hidden   string           `json:"-" prompt:"hidden,string"`
+	Untagged string           `prompt:"untagged,string"`
+	Children []MislabeledItem `json:"children" prompt:"children,object"`
+	Ignored  string           `json:"other"`
+}
+
+type MislabeledItem struct {
+	Label string `json:"text" prompt:"label,string"`
+}
+
+var _ = Describe("CheckTagConsistency", func() {
+	It("should return nil when json and prompt names match", func() {
+		Expect(prompterizer.CheckTagConsistency(Invoice{})).To(Succeed())
+	})
+
+	It("should report json and prompt name mismatches", func() {
+		err := prompterizer.CheckTagConsistency(&[]Mislabeled{})
+
+		Expect(err).To(HaveOccurred())
+
+		var mismatchErr *prompterizer.TagMismatchError
+		Expect(errors.As(err, &mismatchErr)).To(BeTrue())
+		Expect(mismatchErr.Mismatches).To(ConsistOf(
+			prompterizer.TagMismatch{Path: "Mislabeled.Name", JSONName: "fullName", PromptName: "name"},
+			prompterizer.TagMismatch{Path: "Mislabeled.Hidden", PromptName: "hidden"},
+			prompterizer.TagMismatch{Path: "Mislabeled.Children.Label", JSONName: "text", PromptName: "label"},
+		))
+		Expect(err.Error()).To(ContainSubstring("Mislabeled.Name: json name 'fullName' does not match prompt name 'name'"))
+		Expect(err.Error()).To(ContainSubstring("Mislabeled.Hidden: prompt name 'hidden' is excluded from json"))
+	})
+
+	It("should check recursive types", func() {
+		Expect(prompterizer.CheckTagConsistency(OutlineNode{})).To(Succeed())
+	})
+
+	It("should return an error if the value is nil", func() {
+		err := prompterizer.CheckTagConsistency(nil)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("input value for tag consistency check cannot be nil"))
+	})
+
+	It("should fail generating gemini parts when enabled", func() {
+		_, _, _, err := prompterizer.GenerateGeminiParts(prompterizer.PromptParams{
+			ResponseStruct:      Mislabeled{},
+			CheckTagConsistency: true,
+		})
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("json and prompt tags are inconsistent"))
+	})
+})
@@ -0,0 +1,49 @@
+package prompterizer
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// DocumentFormat frames a labeled attachment with the text placed before and after it, and returns the
+// attachment's text as it should appear inside the frame, which is ignored for data and URI attachments.
+// The index counts the labeled attachments of the prompt, starting at 1.
+type DocumentFormat func(index int, attachment Attachment) (header, text, footer string)
+
+// DashDelimited frames documents between dashed lines, the default format
+func DashDelimited() DocumentFormat {
+	return func(_ int, attachment Attachment) (string, string, string) {
+		return fmt.Sprintf("--- %s\n\n", attachment.Label), attachment.Text, "\n\n---\n\n"
+	}
+}
+
+// Only the characters that can form markup are escaped, leaving quotes readable
+var xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// XMLTags wraps documents in <document> tags, which makes it harder for instructions inside a
+// document to be mistaken for the prompt. The text is escaped so it cannot close the tag itself.
+func XMLTags() DocumentFormat {
+	return func(index int, attachment Attachment) (string, string, string) {
+		header := fmt.Sprintf("<document index=\"%d\" name=\"%s\">\n", index, html.EscapeString(attachment.Label))
+		return header, xmlTextEscaper.Replace(attachment.Text), "\n</document>\n\n"
+	}
+}
+
+// MarkdownFences puts documents under a heading in a fenced block, longer than any fence in the text
+func MarkdownFences() DocumentFormat {
+	return func(_ int, attachment Attachment) (string, string, string) {
+		fence := "```"
+		for strings.Contains(attachment.Text, fence) {
+			fence += "`"
+		}
+		return fmt.Sprintf("### %s\n\n%s\n", attachment.Label, fence), attachment.Text, fmt.Sprintf("\n%s\n\n", fence)
+	}
+}
+
+// NumberedDocuments marks the start and end of documents with their number, so prompts can refer to them
+func NumberedDocuments() DocumentFormat {
+	return func(index int, attachment Attachment) (string, string, string) {
+		return fmt.Sprintf("Document %d: %s\n\n", index, attachment.Label), attachment.Text, fmt.Sprintf("\n\nEnd of document %d\n\n", index)
+	}
+}
@@ -0,0 +1,68 @@
+package prompterizer
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+type ExampleFormat int
+
+const (
+	InlineExamples    ExampleFormat = iota // A block of examples in the prompt
+	MultiTurnExamples                      // A user turn and a model turn per example, see GenerateGeminiContents
+)
+
+// Example is an input and the response expected for it, an instance of the response struct
+type Example struct {
+	Input  string
+	Output any
+}
+
+// exampleOutputs serializes the outputs of the examples, returning an error if one does not match the schema
+func exampleOutputs(examples []Example, schema *genai.Schema) ([]string, error) {
+	outputs := make([]string, len(examples))
+	for i, example := range examples {
+		if example.Input == "" {
+			return nil, fmt.Errorf("example %d has no input", i)
+		}
+		if example.Output == nil {
+			return nil, fmt.Errorf("example %d has no output", i)
+		}
+
+		output, err := MarshalResponse(example.Output)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		violations, err := Validate(schema, output)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		if len(violations) > 0 {
+			return nil, fmt.Errorf("example %d: %w", i, &ValidationError{Violations: violations})
+		}
+		outputs[i] = output
+	}
+	return outputs, nil
+}
+
+func inlineExamplesPart(examples []Example, outputs []string) *genai.Part {
+	var block strings.Builder
+	block.WriteString("Examples of inputs and the expected responses:")
+	for i, example := range examples {
+		fmt.Fprintf(&block, "\n\nExample %d\nInput: %s\nResponse: %s", i+1, example.Input, outputs[i])
+	}
+	return genai.NewPartFromText(block.String() + "\n\n")
+}
+
+func exampleContents(examples []Example, outputs []string) []*genai.Content {
+	contents := make([]*genai.Content, 0, 2*len(examples))
+	for i, example := range examples {
+		contents = append(contents,
+			genai.NewContentFromText(example.Input, genai.RoleUser),
+			genai.NewContentFromText(outputs[i], genai.RoleModel),
+		)
+	}
+	return contents
+}
@@ -0,0 +1,102 @@
+package prompterizer_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shopspring/decimal"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+var _ = Describe("Examples", func() {
+	var params prompterizer.PromptParams
+
+	BeforeEach(func() {
+		params = prompterizer.PromptParams{
+			SystemInstructions: []string{"Classify reviews"},
+			Prompt:             []string{"Love it, works perfectly"},
+			ResponseStruct:     Review{},
+			Examples: []prompterizer.Example{
+				{Input: "Broke after a day", Output: Review{Sentiment: "negative", Rating: 1, Note: "not sent"}},
+				{Input: "It's fine", Output: &Review{Sentiment: "neutral", Rating: 3, Product: ReviewProduct{Name: "Mug"}}},
+			},
+		}
+	})
+
+	It("should render an inline examples block before the prompt", func() {
+		_, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].Text).To(Equal("Examples of inputs and the expected responses:\n\n" +
+			"Example 1\nInput: Broke after a day\n" +
+			`Response: {"sentiment":"negative","rating":1,"product":{"name":"","sku":""},"source":""}` + "\n\n" +
+			"Example 2\nInput: It's fine\n" +
+			`Response: {"sentiment":"neutral","rating":3,"product":{"name":"Mug","sku":""},"source":""}` + "\n\n"))
+		Expect(parts[1].Text).To(Equal("Love it, works perfectly"))
+	})
+
+	It("should render multi-turn examples as user and model turns", func() {
+		params.ExampleFormat = prompterizer.MultiTurnExamples
+
+		systemInstruction, contents, schema, err := prompterizer.GenerateGeminiContents(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(systemInstruction.Parts[0].Text).To(Equal("Classify reviews"))
+		Expect(schema.Properties).To(HaveKey("sentiment"))
+		Expect(contents).To(HaveLen(5))
+		Expect(contents[0].Role).To(Equal(genai.RoleUser))
+		Expect(contents[0].Parts[0].Text).To(Equal("Broke after a day"))
+		Expect(contents[1].Role).To(Equal(genai.RoleModel))
+		Expect(contents[1].Parts[0].Text).To(Equal(`{"sentiment":"negative","rating":1,"product":{"name":"","sku":""},"source":""}`))
+		Expect(contents[3].Role).To(Equal(genai.RoleModel))
+		Expect(contents[4].Role).To(Equal(genai.RoleUser))
+		Expect(contents[4].Parts).To(HaveLen(1))
+		Expect(contents[4].Parts[0].Text).To(Equal("Love it, works perfectly"))
+	})
+
+	It("should send multi-turn examples from the generator", func() {
+		params.ExampleFormat = prompterizer.MultiTurnExamples
+		model := &fakeModel{Responses: []*genai.GenerateContentResponse{textResponse(`{"sentiment": "positive", "rating": 5}`)}}
+
+		_, err := prompterizer.NewGeminiGenerator[Review](model, "gemini-test", params, prompterizer.PromptSettings{}).Generate(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(model.Requests[0].Contents).To(HaveLen(5))
+	})
+
+	It("should return an error for examples that do not match the schema", func() {
+		params.ResponseStruct = Shipment{}
+		params.TemplateVariables = map[string]string{"statuses": "pending,shipped"}
+		params.Examples = []prompterizer.Example{{Input: "Shipment SH-1", Output: Shipment{ID: "PKG-1", Status: "lost", Pieces: 1}}}
+
+		_, _, _, err := prompterizer.GenerateGeminiParts(params)
+
+		var validationErr *prompterizer.ValidationError
+		Expect(errors.As(err, &validationErr)).To(BeTrue())
+		Expect(err).To(MatchError("example 0: response does not match schema: $.id: value 'PKG-1' does not match pattern '^SH-[0-9]+$'; $.status: value 'lost' is not one of pending, shipped"))
+	})
+
+	It("should accept examples with decimal fields", func() {
+		params.ResponseStruct = Payment{}
+		params.Examples = []prompterizer.Example{{Input: "Paid 12.50", Output: Payment{Amount: decimal.RequireFromString("12.5")}}}
+
+		_, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts[0].Text).To(ContainSubstring(`Response: {"amount":12.5,"reference":"0"}`))
+	})
+
+	It("should return an error for incomplete examples", func() {
+		params.Examples = []prompterizer.Example{{Output: Review{}}}
+		_, _, _, err := prompterizer.GenerateGeminiParts(params)
+		Expect(err).To(MatchError("example 0 has no input"))
+
+		params.Examples = []prompterizer.Example{{Input: "Review"}}
+		_, _, _, err = prompterizer.GenerateGeminiParts(params)
+		Expect(err).To(MatchError("example 0 has no output"))
+	})
+})
@@ -0,0 +1,151 @@
+package prompterizer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type RepairKind string
+
+const (
+	RepairCodeFence       RepairKind = "code_fence"
+	RepairSurroundingText RepairKind = "surrounding_text"
+	RepairSingleQuotes    RepairKind = "single_quotes"
+	RepairTrailingCommas  RepairKind = "trailing_commas"
+)
+
+type Repair struct {
+	Kind        RepairKind
+	Description string
+}
+
+var codeFenceRegexp = regexp.MustCompile("(?s)```[a-zA-Z]*[ \\t]*\\n?(.*?)```")
+
+// ExtractJSON locates the JSON payload in free-form model output and fixes common defects: markdown
+// code fences, surrounding prose, single-quoted strings and trailing commas. Every change made is
+// returned as a Repair, the payload itself is not guaranteed to be valid JSON.
+func ExtractJSON(text string) (string, []Repair, error) {
+	var repairs []Repair
+
+	payload := strings.TrimSpace(text)
+	if matches := codeFenceRegexp.FindStringSubmatch(payload); matches != nil {
+		if strings.TrimSpace(matches[0]) != payload {
+			repairs = append(repairs, Repair{Kind: RepairSurroundingText, Description: "removed text around the code fence"})
+		}
+		repairs = append(repairs, Repair{Kind: RepairCodeFence, Description: "removed markdown code fence"})
+		payload = strings.TrimSpace(matches[1])
+	}
+
+	start := strings.IndexAny(payload, "{[")
+	if start == -1 {
+		return "", repairs, errors.New("no JSON object or array found in response")
+	}
+	end := findPayloadEnd(payload, start)
+	if end == -1 {
+		return "", repairs, errors.New("JSON payload in response is not terminated")
+	}
+	if start > 0 || end < len(payload) {
+		repairs = append(repairs, Repair{Kind: RepairSurroundingText, Description: fmt.Sprintf("removed %d characters of text around the JSON payload", len(payload)-(end-start))})
+		payload = payload[start:end]
+	}
+
+	payload, tokenRepairs := repairTokens(payload)
+	repairs = append(repairs, tokenRepairs...)
+
+	return payload, repairs, nil
+}
+
+// findPayloadEnd returns the index after the bracket closing the one at start, or -1 if it is never closed
+func findPayloadEnd(text string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(text); i++ {
+		c := text[i]
+
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+func repairTokens(payload string) (string, []Repair) {
+	var (
+		out            strings.Builder
+		quote          byte
+		singleQuotes   int
+		trailingCommas int
+	)
+
+	for i := 0; i < len(payload); i++ {
+		c := payload[i]
+
+		if quote != 0 {
+			switch {
+			case c == '\\' && i+1 < len(payload):
+				i++
+				if quote == '\'' && payload[i] == '\'' {
+					out.WriteByte('\'') // \' is not a valid escape in JSON
+				} else {
+					out.WriteByte(c)
+					out.WriteByte(payload[i])
+				}
+			case c == quote:
+				out.WriteByte('"')
+				quote = 0
+			case c == '"':
+				out.WriteString(`\"`)
+			default:
+				out.WriteByte(c)
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'':
+			if c == '\'' {
+				singleQuotes++
+			}
+			quote = c
+			out.WriteByte('"')
+		case ',':
+			next := strings.TrimLeft(payload[i+1:], " \t\r\n")
+			if strings.HasPrefix(next, "}") || strings.HasPrefix(next, "]") {
+				trailingCommas++
+				continue
+			}
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	var repairs []Repair
+	if singleQuotes > 0 {
+		repairs = append(repairs, Repair{Kind: RepairSingleQuotes, Description: fmt.Sprintf("converted %d single-quoted strings to double quotes", singleQuotes)})
+	}
+	if trailingCommas > 0 {
+		repairs = append(repairs, Repair{Kind: RepairTrailingCommas, Description: fmt.Sprintf("removed %d trailing commas", trailingCommas)})
+	}
+	return out.String(), repairs
+}
@@ -0,0 +1,74 @@
+package prompterizer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+var _ = Describe("ExtractJSON", func() {
+	It("should leave valid JSON untouched", func() {
+		payload, repairs, err := prompterizer.ExtractJSON(` {"name": "It's a \"test\"", "tags": ["a"]} `)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payload).To(Equal(`{"name": "It's a \"test\"", "tags": ["a"]}`))
+		Expect(repairs).To(BeEmpty())
+	})
+
+	It("should remove a markdown code fence", func() {
+		payload, repairs, err := prompterizer.ExtractJSON("```json\n{\"name\": \"value\"}\n```")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payload).To(Equal(`{"name": "value"}`))
+		Expect(repairs).To(ConsistOf(prompterizer.Repair{Kind: prompterizer.RepairCodeFence, Description: "removed markdown code fence"}))
+	})
+
+	It("should remove prose around a code fence", func() {
+		payload, repairs, err := prompterizer.ExtractJSON("Here is the result:\n```\n[1, 2]\n```\nLet me know if you need anything else.")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payload).To(Equal(`[1, 2]`))
+		Expect(repairs).To(ConsistOf(
+			prompterizer.Repair{Kind: prompterizer.RepairSurroundingText, Description: "removed text around the code fence"},
+			prompterizer.Repair{Kind: prompterizer.RepairCodeFence, Description: "removed markdown code fence"},
+		))
+	})
+
+	It("should remove prose around the payload", func() {
+		payload, repairs, err := prompterizer.ExtractJSON(`Sure! {"name": "a } in a string"} Hope that helps.`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payload).To(Equal(`{"name": "a } in a string"}`))
+		Expect(repairs).To(ConsistOf(prompterizer.Repair{Kind: prompterizer.RepairSurroundingText, Description: "removed 23 characters of text around the JSON payload"}))
+	})
+
+	It("should convert single-quoted strings", func() {
+		payload, repairs, err := prompterizer.ExtractJSON(`{'name': 'O\'Brien', 'quote': 'say "hi"'}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payload).To(Equal(`{"name": "O'Brien", "quote": "say \"hi\""}`))
+		Expect(repairs).To(ConsistOf(prompterizer.Repair{Kind: prompterizer.RepairSingleQuotes, Description: "converted 4 single-quoted strings to double quotes"}))
+	})
+
+	It("should remove trailing commas", func() {
+		payload, repairs, err := prompterizer.ExtractJSON("{\"tags\": [\"a\", \"b\",\n], \"name\": \"x,]\",}")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payload).To(Equal("{\"tags\": [\"a\", \"b\"\n], \"name\": \"x,]\"}"))
+		Expect(repairs).To(ConsistOf(prompterizer.Repair{Kind: prompterizer.RepairTrailingCommas, Description: "removed 2 trailing commas"}))
+	})
+
+	It("should return an error if there is no payload", func() {
+		_, _, err := prompterizer.ExtractJSON("I could not find any line items.")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no JSON object or array found in response"))
+	})
+
+	It("should return an error if the payload is not terminated", func() {
+		_, _, err := prompterizer.ExtractJSON(`{"name": "value"`)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("JSON payload in response is not terminated"))
+	})
+})
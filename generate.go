@@ -2,27 +2,47 @@ package prompterizer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 
+	"github.com/samber/lo"
 	"google.golang.org/genai"
 )
 
+const (
+	maxCandidates    = 8
+	maxStopSequences = 5
+)
+
 type PromptParams struct {
-	SystemInstructions []string
-	Prompt             []string
-	FileCategory       string
-	FileContent        string
-	FileData           []byte
-	FileMimeType       *string
-	ResponseStruct     any
-	TemplateVariables  map[string]string
+	SystemInstructions  []string
+	Prompt              []string
+	FileCategory        string
+	FileContent         string
+	FileData            []byte
+	FileMimeType        *string
+	Attachments         []Attachment
+	MaxInlineBytes      int            // Attachments with a URI are referenced instead of sent inline above this size, defaults to 20MB
+	DocumentFormat      DocumentFormat // Frames labeled attachments, defaults to DashDelimited
+	ResponseStruct      any
+	TemplateVariables   map[string]string
+	TemplateData        map[string]any // Richer values for prompt templates, e.g. lists and maps, takes precedence over TemplateVariables
+	RenderTemplates     bool           // Renders SystemInstructions and Prompt as text/template templates
+	CheckTagConsistency bool
+	Examples            []Example
+	ExampleFormat       ExampleFormat
+	History             []Turn // Earlier turns of the conversation, sent before the prompt
 }
 
 type PromptSettings struct {
-	Temperature float64
-	TopP        float64
-	TopK        int
-	Candidates  int
+	Temperature     *float64 // Sampling params are pointers so an explicit zero reaches the model, nil keeps its default
+	TopP            *float64
+	TopK            *int
+	Candidates      int
+	MaxOutputTokens int
+	StopSequences   []string
+	Seed            *int
 }
 
 type PromptGenerator[T any] interface {
@@ -30,33 +50,171 @@ type PromptGenerator[T any] interface {
 }
 
 func GenerateGeminiParts(params PromptParams) (*genai.Content, []*genai.Part, *genai.Schema, error) {
+	render := func(kind, text string) (string, error) {
+		if !params.RenderTemplates {
+			return text, nil
+		}
+		return renderTemplate(kind, text, templateData(params))
+	}
+
 	var systemInstruction *genai.Content
-	for _, instruction := range params.SystemInstructions {
+	for i, instruction := range params.SystemInstructions {
+		instruction, err := render("system instruction", instruction)
+		if err != nil {
+			return nil, nil, &genai.Schema{}, fmt.Errorf("error rendering system instruction %d: %w", i, err)
+		}
 		if systemInstruction == nil {
 			systemInstruction = &genai.Content{}
 		}
 		systemInstruction.Parts = append(systemInstruction.Parts, genai.NewPartFromText(instruction))
 	}
 
-	var promptParts []*genai.Part
-
-	if params.FileCategory != "" && params.FileContent != "" {
-		promptParts = append(promptParts, genai.NewPartFromText(fmt.Sprintf("--- %s\n\n", params.FileCategory)))
-		promptParts = append(promptParts, genai.NewPartFromText(params.FileContent))
-		promptParts = append(promptParts, genai.NewPartFromText("\n\n---\n\n"))
-	}
-	if params.FileData != nil && params.FileMimeType != nil {
-		promptParts = append(promptParts, genai.NewPartFromBytes(params.FileData, *params.FileMimeType))
+	var beforePrompt, afterPrompt []*genai.Part
+	documentIndex := 0
+	for i, attachment := range promptAttachments(params) {
+		if attachment.Label != "" {
+			documentIndex++
+		}
+		parts, err := attachmentParts(attachment, documentIndex, params.DocumentFormat, params.MaxInlineBytes)
+		if err != nil {
+			return nil, nil, &genai.Schema{}, fmt.Errorf("invalid attachment %d: %w", i, err)
+		}
+		if attachment.Placement == AfterPrompt {
+			afterPrompt = append(afterPrompt, parts...)
+		} else {
+			beforePrompt = append(beforePrompt, parts...)
+		}
 	}
 
-	for _, prompt := range params.Prompt {
+	promptParts := beforePrompt
+	for i, prompt := range params.Prompt {
+		prompt, err := render("prompt", prompt)
+		if err != nil {
+			return nil, nil, &genai.Schema{}, fmt.Errorf("error rendering prompt %d: %w", i, err)
+		}
 		promptParts = append(promptParts, genai.NewPartFromText(prompt))
 	}
+	promptParts = append(promptParts, afterPrompt...)
+
+	if params.CheckTagConsistency && params.ResponseStruct != nil {
+		if err := CheckTagConsistency(params.ResponseStruct); err != nil {
+			return nil, nil, &genai.Schema{}, err
+		}
+	}
 
 	responseSchema, err := MarshalResponseSchema(params.ResponseStruct, params.TemplateVariables)
 	if err != nil {
 		return nil, nil, &genai.Schema{}, err
 	}
 
+	outputs, err := exampleOutputs(params.Examples, responseSchema)
+	if err != nil {
+		return nil, nil, &genai.Schema{}, err
+	}
+	if len(params.Examples) > 0 && params.ExampleFormat == InlineExamples {
+		promptParts = append([]*genai.Part{inlineExamplesPart(params.Examples, outputs)}, promptParts...)
+	}
+
 	return systemInstruction, promptParts, responseSchema, nil
 }
+
+// GenerateGeminiContents builds the conversation sent to the model: the turns of multi-turn examples and
+// the history, followed by a user turn with the prompt parts from GenerateGeminiParts
+func GenerateGeminiContents(params PromptParams) (*genai.Content, []*genai.Content, *genai.Schema, error) {
+	systemInstruction, promptParts, responseSchema, err := GenerateGeminiParts(params)
+	if err != nil {
+		return nil, nil, responseSchema, err
+	}
+
+	var contents []*genai.Content
+	if params.ExampleFormat == MultiTurnExamples {
+		outputs, err := exampleOutputs(params.Examples, responseSchema)
+		if err != nil {
+			return nil, nil, responseSchema, err
+		}
+		contents = exampleContents(params.Examples, outputs)
+	}
+
+	history, err := historyContents(params.History)
+	if err != nil {
+		return nil, nil, responseSchema, err
+	}
+	contents = append(contents, history...)
+	contents = append(contents, genai.NewContentFromParts(promptParts, genai.RoleUser))
+
+	return systemInstruction, contents, responseSchema, nil
+}
+
+func GenerateGeminiConfig(params PromptParams, settings PromptSettings) (*genai.GenerateContentConfig, error) {
+	if err := validatePromptSettings(settings); err != nil {
+		return nil, err
+	}
+
+	systemInstruction, _, responseSchema, err := GenerateGeminiParts(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return geminiConfig(systemInstruction, responseSchema, settings), nil
+}
+
+// geminiConfig builds the config from an already generated prompt, with settings that have been validated
+func geminiConfig(systemInstruction *genai.Content, responseSchema *genai.Schema, settings PromptSettings) *genai.GenerateContentConfig {
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: systemInstruction,
+		ResponseMIMEType:  jsonMimeType,
+		ResponseSchema:    responseSchema,
+		CandidateCount:    int32(settings.Candidates),
+		MaxOutputTokens:   int32(settings.MaxOutputTokens),
+		StopSequences:     settings.StopSequences,
+	}
+
+	if settings.Temperature != nil {
+		config.Temperature = lo.ToPtr(float32(*settings.Temperature))
+	}
+	if settings.TopP != nil {
+		config.TopP = lo.ToPtr(float32(*settings.TopP))
+	}
+	if settings.TopK != nil {
+		config.TopK = lo.ToPtr(float32(*settings.TopK))
+	}
+	if settings.Seed != nil {
+		config.Seed = lo.ToPtr(int32(*settings.Seed))
+	}
+
+	return config
+}
+
+func validatePromptSettings(settings PromptSettings) error {
+	var errs []error
+
+	if settings.Temperature != nil && (*settings.Temperature < 0 || *settings.Temperature > 2) {
+		errs = append(errs, fmt.Errorf("temperature must be between 0 and 2, got %v", *settings.Temperature))
+	}
+	if settings.TopP != nil && (*settings.TopP < 0 || *settings.TopP > 1) {
+		errs = append(errs, fmt.Errorf("top p must be between 0 and 1, got %v", *settings.TopP))
+	}
+	if settings.TopK != nil && *settings.TopK < 0 {
+		errs = append(errs, fmt.Errorf("top k must not be negative, got %d", *settings.TopK))
+	}
+	if settings.Candidates < 0 || settings.Candidates > maxCandidates {
+		errs = append(errs, fmt.Errorf("candidates must be between 0 and %d, got %d", maxCandidates, settings.Candidates))
+	}
+	if settings.MaxOutputTokens < 0 || settings.MaxOutputTokens > math.MaxInt32 {
+		errs = append(errs, fmt.Errorf("max output tokens must be between 0 and %d, got %d", math.MaxInt32, settings.MaxOutputTokens))
+	}
+	if len(settings.StopSequences) > maxStopSequences {
+		errs = append(errs, fmt.Errorf("at most %d stop sequences are allowed, got %d", maxStopSequences, len(settings.StopSequences)))
+	}
+	if lo.Contains(settings.StopSequences, "") {
+		errs = append(errs, errors.New("stop sequences must not be empty"))
+	}
+	if settings.Seed != nil && (*settings.Seed < math.MinInt32 || *settings.Seed > math.MaxInt32) {
+		errs = append(errs, fmt.Errorf("seed must fit in 32 bits, got %d", *settings.Seed))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid prompt settings: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -113,3 +113,95 @@ var _ = Describe("GenerateGeminiParts", func() {
 		Expect(schema).To(Not(BeNil()))
 	})
 })
+
+var _ = Describe("GenerateGeminiConfig", func() {
+	var (
+		params   prompterizer.PromptParams
+		settings prompterizer.PromptSettings
+	)
+
+	BeforeEach(func() {
+		params = prompterizer.PromptParams{
+			SystemInstructions: []string{"System instruction"},
+			Prompt:             []string{"Prompt"},
+			ResponseStruct:     ResponseStruct{},
+		}
+		settings = prompterizer.PromptSettings{
+			Temperature:     lo.ToPtr(1.5),
+			TopP:            lo.ToPtr(0.95),
+			TopK:            lo.ToPtr(32),
+			Candidates:      3,
+			MaxOutputTokens: 2048,
+			StopSequences:   []string{"END"},
+			Seed:            lo.ToPtr(42),
+		}
+	})
+
+	It("should generate a complete config", func() {
+		config, err := prompterizer.GenerateGeminiConfig(params, settings)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.SystemInstruction.Parts).To(HaveLen(1))
+		Expect(config.SystemInstruction.Parts[0].Text).To(Equal("System instruction"))
+		Expect(config.ResponseMIMEType).To(Equal("application/json"))
+		Expect(config.ResponseSchema.Properties).To(HaveKey("value"))
+		Expect(*config.Temperature).To(BeNumerically("~", 1.5, 0.0001))
+		Expect(*config.TopP).To(BeNumerically("~", 0.95, 0.0001))
+		Expect(*config.TopK).To(BeNumerically("==", 32))
+		Expect(config.CandidateCount).To(BeNumerically("==", 3))
+		Expect(config.MaxOutputTokens).To(BeNumerically("==", 2048))
+		Expect(config.StopSequences).To(Equal([]string{"END"}))
+		Expect(*config.Seed).To(BeNumerically("==", 42))
+	})
+
+	It("should leave unset sampling params to the model defaults", func() {
+		config, err := prompterizer.GenerateGeminiConfig(params, prompterizer.PromptSettings{})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Temperature).To(BeNil())
+		Expect(config.TopP).To(BeNil())
+		Expect(config.TopK).To(BeNil())
+		Expect(config.Seed).To(BeNil())
+		Expect(config.CandidateCount).To(BeZero())
+		Expect(config.MaxOutputTokens).To(BeZero())
+	})
+
+	It("should send explicit zero sampling params", func() {
+		config, err := prompterizer.GenerateGeminiConfig(params, prompterizer.PromptSettings{Temperature: lo.ToPtr(0.0), TopP: lo.ToPtr(0.0), TopK: lo.ToPtr(0)})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Temperature).To(Equal(lo.ToPtr(float32(0))))
+		Expect(config.TopP).To(Equal(lo.ToPtr(float32(0))))
+		Expect(config.TopK).To(Equal(lo.ToPtr(float32(0))))
+	})
+
+	It("should return an error for out of range settings", func() {
+		settings.Temperature = lo.ToPtr(2.5)
+		settings.TopP = lo.ToPtr(1.5)
+		settings.TopK = lo.ToPtr(-1)
+		settings.Candidates = 9
+		settings.MaxOutputTokens = -1
+		settings.StopSequences = []string{"1", "2", "3", "4", "5", ""}
+
+		_, err := prompterizer.GenerateGeminiConfig(params, settings)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid prompt settings"))
+		Expect(err.Error()).To(ContainSubstring("temperature must be between 0 and 2, got 2.5"))
+		Expect(err.Error()).To(ContainSubstring("top p must be between 0 and 1, got 1.5"))
+		Expect(err.Error()).To(ContainSubstring("top k must not be negative, got -1"))
+		Expect(err.Error()).To(ContainSubstring("candidates must be between 0 and 8, got 9"))
+		Expect(err.Error()).To(ContainSubstring("max output tokens must be between 0 and 2147483647, got -1"))
+		Expect(err.Error()).To(ContainSubstring("at most 5 stop sequences are allowed, got 6"))
+		Expect(err.Error()).To(ContainSubstring("stop sequences must not be empty"))
+	})
+
+	It("should return an error if the response schema cannot be generated", func() {
+		params.ResponseStruct = nil
+
+		_, err := prompterizer.GenerateGeminiConfig(params, settings)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("input value for schema generation cannot be nil"))
+	})
+})
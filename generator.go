@@ -0,0 +1,227 @@
+package prompterizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+const jsonMimeType = "application/json"
+
+// ModelCaller is the subset of the genai Models service used by GeminiGenerator.
+// A *genai.Client satisfies it through its Models field.
+type ModelCaller interface {
+	GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+}
+
+// ModelStreamer is implemented by model callers that support streaming, such as the genai Models service
+type ModelStreamer interface {
+	GenerateContentStream(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error]
+}
+
+type GeminiGenerator[T any] struct {
+	Caller   ModelCaller
+	Model    string
+	Params   PromptParams
+	Settings PromptSettings
+	// Number of times to ask the model for a valid response, following up with the validation
+	// errors after each invalid one. Zero makes a single attempt.
+	MaxAttempts int
+	// How responses are unmarshaled. Responses are repaired, aliases resolved and values coerced before
+	// they are validated. The template variables default to those of the params.
+	UnmarshalOptions UnmarshalOptions
+}
+
+type Attempt struct {
+	RawText string
+	Err     error
+}
+
+var _ PromptGenerator[any] = (*GeminiGenerator[any])(nil)
+
+func NewGeminiGenerator[T any](caller ModelCaller, model string, params PromptParams, settings PromptSettings) *GeminiGenerator[T] {
+	return &GeminiGenerator[T]{
+		Caller:   caller,
+		Model:    model,
+		Params:   params,
+		Settings: settings,
+	}
+}
+
+func (g *GeminiGenerator[T]) Generate(ctx context.Context) (T, error) {
+	out, _, err := g.GenerateWithAttempts(ctx)
+	return out, err
+}
+
+// GenerateWithAttempts validates each response against the schema and, while attempts remain, asks
+// the model to correct an invalid response. Every attempt's raw text and error is returned.
+func (g *GeminiGenerator[T]) GenerateWithAttempts(ctx context.Context) (T, []Attempt, error) {
+	contents, config, err := g.prepareRequest()
+	if err != nil {
+		return *new(T), nil, err
+	}
+
+	var attempts []Attempt
+	for len(attempts) < max(g.MaxAttempts, 1) {
+		response, err := g.Caller.GenerateContent(ctx, g.Model, contents, config)
+		if err != nil {
+			return *new(T), attempts, fmt.Errorf("unable to generate content with model %s: %w", g.Model, err)
+		}
+
+		responseText, err := candidateText(response, 0)
+		if err != nil {
+			attempts = append(attempts, Attempt{Err: err})
+			continue
+		}
+
+		out, err := unmarshalValidated[T](responseText, config.ResponseSchema, g.unmarshalOptions())
+		attempts = append(attempts, Attempt{RawText: responseText, Err: err})
+		if err == nil {
+			return out, attempts, nil
+		}
+
+		correction, err := correctionPrompt(err, config.ResponseSchema)
+		if err != nil {
+			return *new(T), attempts, err
+		}
+		contents = append(contents,
+			genai.NewContentFromText(responseText, genai.RoleModel),
+			genai.NewContentFromText(correction, genai.RoleUser),
+		)
+	}
+
+	lastErr := attempts[len(attempts)-1].Err
+	if len(attempts) == 1 {
+		return *new(T), attempts, lastErr
+	}
+	return *new(T), attempts, fmt.Errorf("no valid response after %d attempts: %w", len(attempts), lastErr)
+}
+
+// GenerateStream streams the response and yields progressively populated values as fields and array
+// items complete, followed by the fully unmarshaled value. The model caller must implement ModelStreamer.
+func (g *GeminiGenerator[T]) GenerateStream(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		streamer, ok := g.Caller.(ModelStreamer)
+		if !ok {
+			yield(*new(T), errors.New("model caller does not support streaming"))
+			return
+		}
+
+		contents, config, err := g.prepareRequest()
+		if err != nil {
+			yield(*new(T), err)
+			return
+		}
+
+		decoder := NewStreamDecoder[T](g.unmarshalOptions())
+		for response, err := range streamer.GenerateContentStream(ctx, g.Model, contents, config) {
+			if err != nil {
+				yield(*new(T), fmt.Errorf("unable to stream content with model %s: %w", g.Model, err))
+				return
+			}
+
+			partial, progressed, err := decoder.Write(chunkText(response))
+			if err != nil {
+				yield(*new(T), err)
+				return
+			}
+			if progressed && !yield(partial, nil) {
+				return
+			}
+		}
+
+		yield(decoder.Close())
+	}
+}
+
+func (g *GeminiGenerator[T]) prepareRequest() ([]*genai.Content, *genai.GenerateContentConfig, error) {
+	if g.Caller == nil {
+		return nil, nil, errors.New("generator has no model caller")
+	}
+
+	params := g.Params
+	if params.ResponseStruct == nil {
+		params.ResponseStruct = new(T)
+	}
+
+	systemInstruction, contents, responseSchema, err := GenerateGeminiContents(params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate prompt contents: %w", err)
+	}
+
+	if err := validatePromptSettings(g.Settings); err != nil {
+		return nil, nil, fmt.Errorf("unable to generate prompt config: %w", err)
+	}
+
+	return contents, geminiConfig(systemInstruction, responseSchema, g.Settings), nil
+}
+
+func unmarshalValidated[T any](responseText string, schema *genai.Schema, options UnmarshalOptions) (T, error) {
+	out, _, err := decodeResponse[T](responseText, options, schema)
+	return out, err
+}
+
+// unmarshalOptions returns the unmarshal options, with the template variables of the params if none are set
+func (g *GeminiGenerator[T]) unmarshalOptions() UnmarshalOptions {
+	options := g.UnmarshalOptions
+	if options.TemplateVariables == nil {
+		options.TemplateVariables = g.Params.TemplateVariables
+	}
+	return options
+}
+
+func correctionPrompt(responseErr error, schema *genai.Schema) (string, error) {
+	schemaJson, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal response schema: %w", err)
+	}
+
+	return fmt.Sprintf(
+		"Your previous response was invalid: %s\n\nRespond again with only the corrected JSON, matching this schema:\n%s",
+		responseErr, schemaJson,
+	), nil
+}
+
+func candidateText(response *genai.GenerateContentResponse, index int) (string, error) {
+	if response == nil || len(response.Candidates) <= index {
+		return "", fmt.Errorf("prompt response has no candidate at index %d", index)
+	}
+
+	candidate := response.Candidates[index]
+	if candidate.Content == nil || len(candidate.Content.Parts) == 0 {
+		return "", fmt.Errorf("prompt response candidate %d has no content (finish reason: %s)", index, candidate.FinishReason)
+	}
+
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if part.Thought {
+			continue
+		}
+		text.WriteString(part.Text)
+	}
+
+	if text.Len() == 0 {
+		return "", fmt.Errorf("prompt response candidate %d has no text (finish reason: %s)", index, candidate.FinishReason)
+	}
+	return text.String(), nil
+}
+
+// chunkText returns the text of the first candidate of a streamed response, chunks without text are expected
+func chunkText(response *genai.GenerateContentResponse) string {
+	if response == nil || len(response.Candidates) == 0 || response.Candidates[0].Content == nil {
+		return ""
+	}
+
+	var text strings.Builder
+	for _, part := range response.Candidates[0].Content.Parts {
+		if !part.Thought {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
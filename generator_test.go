@@ -0,0 +1,365 @@
+package prompterizer_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+type fakeRequest struct {
+	Model    string
+	Contents []*genai.Content
+	Config   *genai.GenerateContentConfig
+}
+
+type fakeModel struct {
+	Responses []*genai.GenerateContentResponse
+	Chunks    []string
+	Err       error
+	Requests  []fakeRequest
+}
+
+func (f *fakeModel) GenerateContent(_ context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	f.Requests = append(f.Requests, fakeRequest{Model: model, Contents: contents, Config: config})
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if len(f.Responses) == 0 {
+		return nil, errors.New("fake model has no scripted responses left")
+	}
+
+	response := f.Responses[0]
+	f.Responses = f.Responses[1:]
+	return response, nil
+}
+
+func (f *fakeModel) GenerateContentStream(_ context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error] {
+	f.Requests = append(f.Requests, fakeRequest{Model: model, Contents: contents, Config: config})
+	return func(yield func(*genai.GenerateContentResponse, error) bool) {
+		for _, chunk := range f.Chunks {
+			if !yield(textResponse(chunk), nil) {
+				return
+			}
+		}
+		if f.Err != nil {
+			yield(nil, f.Err)
+		}
+	}
+}
+
+func textResponse(texts ...string) *genai.GenerateContentResponse {
+	response := &genai.GenerateContentResponse{}
+	for _, text := range texts {
+		response.Candidates = append(response.Candidates, &genai.Candidate{
+			Content:      genai.NewContentFromText(text, genai.RoleModel),
+			FinishReason: genai.FinishReasonStop,
+		})
+	}
+	return response
+}
+
+type InvoiceTotal struct {
+	Total    int    `json:"total" prompt:"total,integer,required" prompt_aliases:"sum"`
+	Currency string `json:"currencyCode" prompt:"currency,string,required"`
+}
+
+var _ = Describe("GeminiGenerator", func() {
+	var (
+		model     *fakeModel
+		generator *prompterizer.GeminiGenerator[ResponseStruct]
+	)
+
+	BeforeEach(func() {
+		model = &fakeModel{}
+		generator = prompterizer.NewGeminiGenerator[ResponseStruct](model, "gemini-test", prompterizer.PromptParams{
+			SystemInstructions: []string{"System instruction"},
+			Prompt:             []string{"Prompt"},
+		}, prompterizer.PromptSettings{
+			Temperature: lo.ToPtr(0.5),
+			TopP:        lo.ToPtr(0.9),
+			TopK:        lo.ToPtr(40),
+			Candidates:  1,
+		})
+	})
+
+	It("should generate a typed response", func() {
+		model.Responses = []*genai.GenerateContentResponse{textResponse(`{"value": "generated"}`)}
+
+		response, err := generator.Generate(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Value).To(Equal("generated"))
+	})
+
+	It("should send the prompt, schema and settings to the model", func() {
+		model.Responses = []*genai.GenerateContentResponse{textResponse(`{"value": "generated"}`)}
+
+		_, err := generator.Generate(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(model.Requests).To(HaveLen(1))
+		request := model.Requests[0]
+		Expect(request.Model).To(Equal("gemini-test"))
+		Expect(request.Contents).To(HaveLen(1))
+		Expect(request.Contents[0].Role).To(Equal(genai.RoleUser))
+		Expect(request.Contents[0].Parts[0].Text).To(Equal("Prompt"))
+
+		Expect(request.Config.SystemInstruction.Parts[0].Text).To(Equal("System instruction"))
+		Expect(request.Config.ResponseMIMEType).To(Equal("application/json"))
+		Expect(request.Config.ResponseSchema.Properties).To(HaveKey("value"))
+		Expect(*request.Config.Temperature).To(BeNumerically("~", 0.5, 0.0001))
+		Expect(*request.Config.TopP).To(BeNumerically("~", 0.9, 0.0001))
+		Expect(*request.Config.TopK).To(BeNumerically("==", 40))
+		Expect(request.Config.CandidateCount).To(BeNumerically("==", 1))
+	})
+
+	It("should render the prompt once per request", func() {
+		renders := 0
+		generator.Params.SystemInstructions = []string{"{{call .render}}"}
+		generator.Params.RenderTemplates = true
+		generator.Params.TemplateData = map[string]any{"render": func() string { renders++; return "Rendered" }}
+		model.Responses = []*genai.GenerateContentResponse{textResponse(`{"value": "generated"}`)}
+
+		_, err := generator.Generate(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(renders).To(Equal(1))
+		Expect(model.Requests[0].Config.SystemInstruction.Parts[0].Text).To(Equal("Rendered"))
+	})
+
+	It("should return an error if the model call fails", func() {
+		model.Err = errors.New("quota exceeded")
+
+		_, err := generator.Generate(context.Background())
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to generate content with model gemini-test: quota exceeded"))
+	})
+
+	It("should return an error if the response has no candidates", func() {
+		model.Responses = []*genai.GenerateContentResponse{{}}
+
+		_, err := generator.Generate(context.Background())
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("prompt response has no candidate at index 0"))
+	})
+
+	It("should return an error if the response cannot be unmarshaled", func() {
+		model.Responses = []*genai.GenerateContentResponse{textResponse(`not json`)}
+
+		_, err := generator.Generate(context.Background())
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to unmarshal prompt response 'not json'"))
+	})
+
+	Context("retries", func() {
+		var shipmentGenerator *prompterizer.GeminiGenerator[Shipment]
+
+		BeforeEach(func() {
+			shipmentGenerator = prompterizer.NewGeminiGenerator[Shipment](model, "gemini-test", prompterizer.PromptParams{
+				Prompt:            []string{"Extract the shipment"},
+				TemplateVariables: map[string]string{"statuses": "pending,shipped,delivered"},
+			}, prompterizer.PromptSettings{})
+			shipmentGenerator.MaxAttempts = 3
+		})
+
+		It("should ask the model to correct an invalid response", func() {
+			model.Responses = []*genai.GenerateContentResponse{
+				textResponse(`{"id": "SH-1", "status": "lost"}`),
+				textResponse(`{"id": "SH-1", "status": "pending"}`),
+			}
+
+			shipment, attempts, err := shipmentGenerator.GenerateWithAttempts(context.Background())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shipment.Status).To(Equal("pending"))
+			Expect(attempts).To(HaveLen(2))
+			Expect(attempts[0].RawText).To(Equal(`{"id": "SH-1", "status": "lost"}`))
+			Expect(attempts[0].Err).To(MatchError(ContainSubstring("$.status: value 'lost' is not one of pending, shipped, delivered")))
+			Expect(attempts[1].Err).ToNot(HaveOccurred())
+
+			Expect(model.Requests).To(HaveLen(2))
+			followUp := model.Requests[1].Contents
+			Expect(followUp).To(HaveLen(3))
+			Expect(followUp[1].Role).To(Equal(genai.RoleModel))
+			Expect(followUp[1].Parts[0].Text).To(Equal(`{"id": "SH-1", "status": "lost"}`))
+			Expect(followUp[2].Role).To(Equal(genai.RoleUser))
+			Expect(followUp[2].Parts[0].Text).To(ContainSubstring("Your previous response was invalid: response does not match schema: $.status: value 'lost' is not one of pending, shipped, delivered"))
+			Expect(followUp[2].Parts[0].Text).To(ContainSubstring(`"enum": [`))
+		})
+
+		It("should retry a response that cannot be unmarshaled", func() {
+			model.Responses = []*genai.GenerateContentResponse{
+				textResponse(`{"id": "SH-1",`),
+				textResponse(`{"id": "SH-1", "status": "shipped"}`),
+			}
+
+			shipment, err := shipmentGenerator.Generate(context.Background())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shipment.Status).To(Equal("shipped"))
+		})
+
+		It("should return every attempt when no valid response is generated", func() {
+			model.Responses = []*genai.GenerateContentResponse{
+				textResponse(`{"id": "SH-1"}`),
+				{},
+				textResponse(`{"status": "shipped"}`),
+			}
+
+			_, attempts, err := shipmentGenerator.GenerateWithAttempts(context.Background())
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no valid response after 3 attempts: response does not match schema: $.id: required property is missing"))
+			Expect(attempts).To(HaveLen(3))
+			Expect(attempts[0].Err).To(MatchError(ContainSubstring("$.status: required property is missing")))
+			Expect(attempts[1].RawText).To(BeEmpty())
+			Expect(attempts[1].Err).To(MatchError(ContainSubstring("prompt response has no candidate at index 0")))
+			Expect(attempts[2].RawText).To(Equal(`{"status": "shipped"}`))
+		})
+
+		It("should make a single attempt by default", func() {
+			shipmentGenerator.MaxAttempts = 0
+			model.Responses = []*genai.GenerateContentResponse{textResponse(`{"id": "SH-1"}`)}
+
+			_, attempts, err := shipmentGenerator.GenerateWithAttempts(context.Background())
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("response does not match schema"))
+			Expect(attempts).To(HaveLen(1))
+			Expect(model.Requests).To(HaveLen(1))
+		})
+	})
+
+	Context("streaming", func() {
+		var streamGenerator *prompterizer.GeminiGenerator[[]Event]
+
+		BeforeEach(func() {
+			streamGenerator = prompterizer.NewGeminiGenerator[[]Event](model, "gemini-test", prompterizer.PromptParams{
+				Prompt: []string{"List the events"},
+			}, prompterizer.PromptSettings{})
+		})
+
+		It("should yield progressively populated values", func() {
+			model.Chunks = []string{`[{"name": "Kick`, `off"}, {"na`, `me": "Lunch"}`, `]`}
+
+			var values [][]Event
+			for value, err := range streamGenerator.GenerateStream(context.Background()) {
+				Expect(err).ToNot(HaveOccurred())
+				values = append(values, value)
+			}
+
+			Expect(values).To(Equal([][]Event{
+				{{}},
+				{{Name: "Kickoff"}, {}},
+				{{Name: "Kickoff"}, {Name: "Lunch"}},
+				{{Name: "Kickoff"}, {Name: "Lunch"}},
+				{{Name: "Kickoff"}, {Name: "Lunch"}},
+			}))
+			Expect(model.Requests[0].Config.ResponseSchema.Type).To(Equal(genai.TypeArray))
+		})
+
+		It("should yield an error if the stream fails", func() {
+			model.Chunks = []string{`[{"name": "Kickoff"}`}
+			model.Err = errors.New("connection reset")
+
+			var errs []error
+			for _, err := range streamGenerator.GenerateStream(context.Background()) {
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Error()).To(ContainSubstring("unable to stream content with model gemini-test: connection reset"))
+		})
+
+		It("should yield an error if the model caller does not support streaming", func() {
+			streamGenerator.Caller = struct{ prompterizer.ModelCaller }{model}
+
+			for _, err := range streamGenerator.GenerateStream(context.Background()) {
+				Expect(err).To(MatchError("model caller does not support streaming"))
+			}
+		})
+	})
+
+	Describe("UnmarshalOptions", func() {
+		var totalGenerator *prompterizer.GeminiGenerator[InvoiceTotal]
+
+		BeforeEach(func() {
+			totalGenerator = prompterizer.NewGeminiGenerator[InvoiceTotal](model, "gemini-test", prompterizer.PromptParams{
+				Prompt: []string{"Total the invoice"},
+			}, prompterizer.PromptSettings{})
+		})
+
+		It("should resolve aliases of required fields before validating", func() {
+			model.Responses = []*genai.GenerateContentResponse{textResponse(`{"sum": 5, "currency": "USD"}`)}
+
+			total, err := totalGenerator.Generate(context.Background())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(total.Total).To(Equal(5))
+		})
+
+		It("should repair and coerce the response before validating", func() {
+			totalGenerator.UnmarshalOptions = prompterizer.UnmarshalOptions{Lenient: true, Coerce: true}
+			model.Responses = []*genai.GenerateContentResponse{textResponse("```json\n{\"total\": \"1,234\", \"currency\": 'USD',}\n```")}
+
+			total, err := totalGenerator.Generate(context.Background())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(total.Total).To(Equal(1234))
+		})
+
+		It("should populate fields by their prompt names", func() {
+			totalGenerator.UnmarshalOptions = prompterizer.UnmarshalOptions{ByPromptTags: true}
+			model.Responses = []*genai.GenerateContentResponse{textResponse(`{"total": 5, "currency": "USD"}`)}
+
+			total, err := totalGenerator.Generate(context.Background())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(total.Currency).To(Equal("USD"))
+		})
+
+		It("should still report violations after normalizing", func() {
+			totalGenerator.UnmarshalOptions = prompterizer.UnmarshalOptions{Coerce: true}
+			model.Responses = []*genai.GenerateContentResponse{textResponse(`{"total": "many"}`)}
+
+			_, err := totalGenerator.Generate(context.Background())
+
+			Expect(err).To(MatchError("response does not match schema: $.currency: required property is missing; $.total: expected integer, got string"))
+		})
+
+		It("should apply the options to candidates", func() {
+			totalGenerator.UnmarshalOptions = prompterizer.UnmarshalOptions{Coerce: true}
+			model.Responses = []*genai.GenerateContentResponse{textResponse(`{"total": "5", "currency": "USD"}`, `{"sum": 6, "currency": "USD"}`)}
+
+			candidates, err := totalGenerator.GenerateCandidates(context.Background())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(candidates[0].Value.Total).To(Equal(5))
+			Expect(candidates[1].Value.Total).To(Equal(6))
+		})
+
+		It("should apply the options to streamed values", func() {
+			totalGenerator.UnmarshalOptions = prompterizer.UnmarshalOptions{Coerce: true}
+			model.Chunks = []string{`{"total": "7", `, `"currency": "USD"}`}
+
+			var values []InvoiceTotal
+			for value, err := range totalGenerator.GenerateStream(context.Background()) {
+				Expect(err).ToNot(HaveOccurred())
+				values = append(values, value)
+			}
+
+			Expect(values[len(values)-1].Total).To(Equal(7))
+		})
+	})
+})
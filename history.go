@@ -0,0 +1,41 @@
+package prompterizer
+
+import (
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// Turn is a previous message of the conversation, either text or a structured response from an earlier
+// prompt, serialized with MarshalResponse
+type Turn struct {
+	Role   genai.Role
+	Text   string
+	Output any
+}
+
+func historyContents(history []Turn) ([]*genai.Content, error) {
+	contents := make([]*genai.Content, 0, len(history))
+	for i, turn := range history {
+		if turn.Role != genai.RoleUser && turn.Role != genai.RoleModel {
+			return nil, fmt.Errorf("history turn %d has unsupported role '%s'", i, turn.Role)
+		}
+
+		text := turn.Text
+		switch {
+		case text != "" && turn.Output != nil:
+			return nil, fmt.Errorf("history turn %d sets both text and output", i)
+		case turn.Output != nil:
+			output, err := MarshalResponse(turn.Output)
+			if err != nil {
+				return nil, fmt.Errorf("history turn %d: %w", i, err)
+			}
+			text = output
+		case text == "":
+			return nil, fmt.Errorf("history turn %d has no text or output", i)
+		}
+
+		contents = append(contents, genai.NewContentFromText(text, turn.Role))
+	}
+	return contents, nil
+}
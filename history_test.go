@@ -0,0 +1,83 @@
+package prompterizer_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shopspring/decimal"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+var _ = Describe("History", func() {
+	var params prompterizer.PromptParams
+
+	BeforeEach(func() {
+		params = prompterizer.PromptParams{
+			Prompt:         []string{"Now refine the rating, the customer returned the product"},
+			ResponseStruct: Review{},
+			History: []prompterizer.Turn{
+				{Role: genai.RoleUser, Text: "Classify: Love it"},
+				{Role: genai.RoleModel, Output: Review{Sentiment: "positive", Rating: 5}},
+			},
+		}
+	})
+
+	It("should send the history before the prompt", func() {
+		_, contents, _, err := prompterizer.GenerateGeminiContents(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(HaveLen(3))
+		Expect(contents[0].Role).To(Equal(genai.RoleUser))
+		Expect(contents[0].Parts[0].Text).To(Equal("Classify: Love it"))
+		Expect(contents[1].Role).To(Equal(genai.RoleModel))
+		Expect(contents[1].Parts[0].Text).To(Equal(`{"sentiment":"positive","rating":5,"product":{"name":"","sku":""},"source":""}`))
+		Expect(contents[2].Role).To(Equal(genai.RoleUser))
+		Expect(contents[2].Parts[0].Text).To(Equal("Now refine the rating, the customer returned the product"))
+	})
+
+	It("should send the history after multi-turn examples", func() {
+		params.Examples = []prompterizer.Example{{Input: "Classify: Meh", Output: Review{Sentiment: "neutral", Rating: 3}}}
+		params.ExampleFormat = prompterizer.MultiTurnExamples
+
+		_, contents, _, err := prompterizer.GenerateGeminiContents(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(HaveLen(5))
+		Expect(contents[0].Parts[0].Text).To(Equal("Classify: Meh"))
+		Expect(contents[2].Parts[0].Text).To(Equal("Classify: Love it"))
+	})
+
+	It("should send the history from the generator", func() {
+		model := &fakeModel{Responses: []*genai.GenerateContentResponse{textResponse(`{"sentiment": "negative", "rating": 2}`)}}
+
+		review, err := prompterizer.NewGeminiGenerator[Review](model, "gemini-test", params, prompterizer.PromptSettings{}).Generate(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(review.Rating).To(Equal(2))
+		Expect(model.Requests[0].Contents).To(HaveLen(3))
+	})
+
+	It("should write decimal outputs as numbers", func() {
+		params.History = []prompterizer.Turn{{Role: genai.RoleModel, Output: Payment{Amount: decimal.RequireFromString("12.5")}}}
+
+		_, contents, _, err := prompterizer.GenerateGeminiContents(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents[0].Parts[0].Text).To(Equal(`{"amount":12.5,"reference":"0"}`))
+	})
+
+	DescribeTable("should return an error for invalid turns",
+		func(turn prompterizer.Turn, message string) {
+			params.History = append(params.History, turn)
+
+			_, _, _, err := prompterizer.GenerateGeminiContents(params)
+
+			Expect(err).To(MatchError(message))
+		},
+		Entry("unsupported role", prompterizer.Turn{Role: "system", Text: "Be brief"}, "history turn 2 has unsupported role 'system'"),
+		Entry("text and output", prompterizer.Turn{Role: genai.RoleModel, Text: "{}", Output: Review{}}, "history turn 2 sets both text and output"),
+		Entry("empty", prompterizer.Turn{Role: genai.RoleUser}, "history turn 2 has no text or output"),
+	)
+})
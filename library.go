@@ -0,0 +1,222 @@
+package prompterizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// PromptDefinition is a prompt kept in a YAML or JSON file so it can be reviewed and versioned apart from code
+type PromptDefinition struct {
+	Name               string             `json:"name" yaml:"name"`
+	Version            string             `json:"version" yaml:"version"`
+	Description        string             `json:"description,omitempty" yaml:"description,omitempty"`
+	SystemInstructions []string           `json:"systemInstructions,omitempty" yaml:"systemInstructions,omitempty"`
+	Prompt             []string           `json:"prompt" yaml:"prompt"`
+	ResponseType       string             `json:"responseType" yaml:"responseType"` // Name given to RegisterResponseType
+	TemplateVariables  map[string]string  `json:"templateVariables,omitempty" yaml:"templateVariables,omitempty"`
+	Settings           DefinitionSettings `json:"settings" yaml:"settings"`
+}
+
+type DefinitionSettings struct {
+	Temperature     *float64 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty" yaml:"topP,omitempty"`
+	TopK            *int     `json:"topK,omitempty" yaml:"topK,omitempty"`
+	Candidates      int      `json:"candidates,omitempty" yaml:"candidates,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty" yaml:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty" yaml:"stopSequences,omitempty"`
+	Seed            *int     `json:"seed,omitempty" yaml:"seed,omitempty"`
+}
+
+// PromptLibrary is a registry of prompt definitions keyed by name and version
+type PromptLibrary struct {
+	definitions   map[string]map[string]PromptDefinition
+	responseTypes map[string]reflect.Type
+}
+
+func NewPromptLibrary() *PromptLibrary {
+	return &PromptLibrary{
+		definitions:   map[string]map[string]PromptDefinition{},
+		responseTypes: map[string]reflect.Type{},
+	}
+}
+
+// RegisterResponseType makes T available to prompt definitions under the given response type name
+func RegisterResponseType[T any](library *PromptLibrary, name string) {
+	library.responseTypes[name] = reflect.TypeFor[T]()
+}
+
+// Add registers a definition, returning an error if its name and version are already registered
+func (l *PromptLibrary) Add(definition PromptDefinition) error {
+	if definition.Name == "" || definition.Version == "" {
+		return errors.New("prompt definitions require a name and a version")
+	}
+	if len(definition.Prompt) == 0 {
+		return fmt.Errorf("prompt %s version %s has no prompt", definition.Name, definition.Version)
+	}
+	if err := validatePromptSettings(definition.Settings.promptSettings()); err != nil {
+		return fmt.Errorf("prompt %s version %s: %w", definition.Name, definition.Version, err)
+	}
+
+	versions, ok := l.definitions[definition.Name]
+	if !ok {
+		versions = map[string]PromptDefinition{}
+		l.definitions[definition.Name] = versions
+	}
+	if _, ok := versions[definition.Version]; ok {
+		return fmt.Errorf("prompt %s version %s is already registered", definition.Name, definition.Version)
+	}
+	// Versions that only differ in their spelling, e.g. v1.2 and 1.2, would make the latest version ambiguous
+	for version := range versions {
+		if compareVersions(version, definition.Version) == 0 {
+			return fmt.Errorf("prompt %s version %s is already registered as %s", definition.Name, definition.Version, version)
+		}
+	}
+	versions[definition.Version] = definition
+	return nil
+}
+
+// Load parses a single definition from YAML or JSON, chosen by the file extension
+func (l *PromptLibrary) Load(name string, data []byte) error {
+	var definition PromptDefinition
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&definition); err != nil {
+			return fmt.Errorf("unable to load prompt definition %s: %w", name, err)
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&definition); err != nil {
+			return fmt.Errorf("unable to load prompt definition %s: %w", name, err)
+		}
+	default:
+		return fmt.Errorf("unable to load prompt definition %s: unsupported file extension", name)
+	}
+
+	if err := l.Add(definition); err != nil {
+		return fmt.Errorf("unable to load prompt definition %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadFS loads every .yaml, .yml and .json file under root, e.g. from an embed.FS
+func (l *PromptLibrary) LoadFS(fsys fs.FS, root string) error {
+	return fs.WalkDir(fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !lo.Contains([]string{".yaml", ".yml", ".json"}, strings.ToLower(path.Ext(name))) {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("unable to load prompt definition %s: %w", name, err)
+		}
+		return l.Load(name, data)
+	})
+}
+
+// Get returns a version of a prompt, the latest version if version is empty
+func (l *PromptLibrary) Get(name, version string) (PromptDefinition, error) {
+	versions, ok := l.definitions[name]
+	if !ok {
+		return PromptDefinition{}, fmt.Errorf("prompt %s not found", name)
+	}
+	if version == "" {
+		allVersions := l.Versions(name)
+		version = allVersions[len(allVersions)-1]
+	}
+
+	definition, ok := versions[version]
+	if !ok {
+		return PromptDefinition{}, fmt.Errorf("prompt %s has no version %s", name, version)
+	}
+	return definition, nil
+}
+
+// Versions lists the registered versions of a prompt from oldest to latest
+func (l *PromptLibrary) Versions(name string) []string {
+	versions := lo.Keys(l.definitions[name])
+	slices.SortFunc(versions, compareVersions)
+	return versions
+}
+
+// Params builds the prompt params and settings of a version of a prompt, the latest version if version
+// is empty. The response struct is a new value of the registered response type.
+func (l *PromptLibrary) Params(name, version string) (PromptParams, PromptSettings, error) {
+	definition, err := l.Get(name, version)
+	if err != nil {
+		return PromptParams{}, PromptSettings{}, err
+	}
+
+	params := PromptParams{
+		SystemInstructions: definition.SystemInstructions,
+		Prompt:             definition.Prompt,
+		TemplateVariables:  definition.TemplateVariables,
+		RenderTemplates:    true,
+	}
+	if definition.ResponseType != "" {
+		responseType, ok := l.responseTypes[definition.ResponseType]
+		if !ok {
+			return PromptParams{}, PromptSettings{}, fmt.Errorf("unknown response type %s for prompt %s version %s", definition.ResponseType, definition.Name, definition.Version)
+		}
+		params.ResponseStruct = reflect.New(responseType).Interface()
+	}
+
+	return params, definition.Settings.promptSettings(), nil
+}
+
+// NewLibraryGenerator creates a generator for a version of a prompt, the latest version if version is
+// empty, checking that the prompt's response type is T
+func NewLibraryGenerator[T any](library *PromptLibrary, caller ModelCaller, model, name, version string) (*GeminiGenerator[T], error) {
+	params, settings, err := library.Params(name, version)
+	if err != nil {
+		return nil, err
+	}
+	if params.ResponseStruct != nil {
+		if responseType := reflect.TypeOf(params.ResponseStruct).Elem(); responseType != reflect.TypeFor[T]() {
+			return nil, fmt.Errorf("prompt %s responds with %s, not %s", name, responseType, reflect.TypeFor[T]())
+		}
+	}
+	return NewGeminiGenerator[T](caller, model, params, settings), nil
+}
+
+func (s DefinitionSettings) promptSettings() PromptSettings {
+	return PromptSettings(s)
+}
+
+// compareVersions orders versions by their dot separated segments, numerically where both segments are numbers,
+// so that "1.10" comes after "1.9". A leading "v" is ignored.
+func compareVersions(a, b string) int {
+	aSegments := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bSegments := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < min(len(aSegments), len(bSegments)); i++ {
+		aNumber, aErr := strconv.Atoi(aSegments[i])
+		bNumber, bErr := strconv.Atoi(bSegments[i])
+		if aErr == nil && bErr == nil {
+			if aNumber != bNumber {
+				return aNumber - bNumber
+			}
+			continue
+		}
+		if c := strings.Compare(aSegments[i], bSegments[i]); c != 0 {
+			return c
+		}
+	}
+	return len(aSegments) - len(bSegments)
+}
@@ -0,0 +1,152 @@
+package prompterizer_test
+
+import (
+	"context"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+var _ = Describe("PromptLibrary", func() {
+	var (
+		library *prompterizer.PromptLibrary
+		files   fstest.MapFS
+	)
+
+	BeforeEach(func() {
+		library = prompterizer.NewPromptLibrary()
+		prompterizer.RegisterResponseType[Review](library, "review")
+
+		files = fstest.MapFS{
+			"prompts/classify-review/1.yaml": {Data: []byte(`
+name: classify-review
+version: "1.9"
+systemInstructions:
+  - You classify product reviews.
+prompt:
+  - "Classify this review: {{.review}}"
+responseType: review
+settings:
+  temperature: 0.2
+  maxOutputTokens: 512
+`)},
+			"prompts/classify-review/2.json": {Data: []byte(`{
+				"name": "classify-review",
+				"version": "1.10",
+				"prompt": ["Classify the sentiment of this review: {{.review}}"],
+				"responseType": "review",
+				"settings": {"candidates": 3, "stopSequences": ["END"]}
+			}`)},
+			"prompts/README.md": {Data: []byte("Not a prompt")},
+		}
+	})
+
+	It("should load definitions from a file system", func() {
+		Expect(library.LoadFS(files, "prompts")).To(Succeed())
+
+		Expect(library.Versions("classify-review")).To(Equal([]string{"1.9", "1.10"}))
+
+		definition, err := library.Get("classify-review", "1.9")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(definition.SystemInstructions).To(Equal([]string{"You classify product reviews."}))
+		Expect(definition.Settings.Temperature).To(Equal(lo.ToPtr(0.2)))
+		Expect(definition.Settings.MaxOutputTokens).To(Equal(512))
+	})
+
+	It("should build params and settings for the latest version by default", func() {
+		Expect(library.LoadFS(files, "prompts")).To(Succeed())
+
+		params, settings, err := library.Params("classify-review", "")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(params.Prompt).To(Equal([]string{"Classify the sentiment of this review: {{.review}}"}))
+		Expect(params.RenderTemplates).To(BeTrue())
+		Expect(params.ResponseStruct).To(Equal(&Review{}))
+		Expect(settings.Candidates).To(Equal(3))
+		Expect(settings.StopSequences).To(Equal([]string{"END"}))
+	})
+
+	It("should build params for a pinned version", func() {
+		Expect(library.LoadFS(files, "prompts")).To(Succeed())
+
+		params, settings, err := library.Params("classify-review", "1.9")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(params.SystemInstructions).To(Equal([]string{"You classify product reviews."}))
+		Expect(settings.Temperature).To(Equal(lo.ToPtr(0.2)))
+	})
+
+	It("should create a typed generator", func() {
+		Expect(library.LoadFS(files, "prompts")).To(Succeed())
+		model := &fakeModel{Responses: []*genai.GenerateContentResponse{textResponse(`{"sentiment": "positive", "rating": 5}`)}}
+
+		generator, err := prompterizer.NewLibraryGenerator[Review](library, model, "gemini-test", "classify-review", "1.9")
+		Expect(err).ToNot(HaveOccurred())
+		generator.Params.TemplateVariables = map[string]string{"review": "Great product"}
+
+		review, err := generator.Generate(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(review.Sentiment).To(Equal("positive"))
+		Expect(model.Requests[0].Contents[0].Parts[0].Text).To(Equal("Classify this review: Great product"))
+	})
+
+	It("should return an error if the generator type does not match the response type", func() {
+		Expect(library.LoadFS(files, "prompts")).To(Succeed())
+
+		_, err := prompterizer.NewLibraryGenerator[Receipt](library, &fakeModel{}, "gemini-test", "classify-review", "")
+
+		Expect(err).To(MatchError("prompt classify-review responds with prompterizer_test.Review, not prompterizer_test.Receipt"))
+	})
+
+	It("should return an error for unregistered response types", func() {
+		Expect(library.Add(prompterizer.PromptDefinition{Name: "summarize", Version: "1", Prompt: []string{"Summarize"}, ResponseType: "summary"})).To(Succeed())
+
+		_, _, err := library.Params("summarize", "1")
+
+		Expect(err).To(MatchError("unknown response type summary for prompt summarize version 1"))
+	})
+
+	It("should return an error for duplicate versions", func() {
+		definition := prompterizer.PromptDefinition{Name: "summarize", Version: "1", Prompt: []string{"Summarize"}}
+		Expect(library.Add(definition)).To(Succeed())
+
+		Expect(library.Add(definition)).To(MatchError("prompt summarize version 1 is already registered"))
+	})
+
+	It("should return an error for versions equal to a registered version", func() {
+		definition := prompterizer.PromptDefinition{Name: "summarize", Version: "1.2", Prompt: []string{"Summarize"}}
+		Expect(library.Add(definition)).To(Succeed())
+
+		definition.Version = "v1.2"
+		Expect(library.Add(definition)).To(MatchError("prompt summarize version v1.2 is already registered as 1.2"))
+	})
+
+	It("should return an error for invalid definitions", func() {
+		Expect(library.Add(prompterizer.PromptDefinition{Name: "summarize", Prompt: []string{"Summarize"}})).To(MatchError("prompt definitions require a name and a version"))
+		Expect(library.Add(prompterizer.PromptDefinition{Name: "summarize", Version: "1"})).To(MatchError("prompt summarize version 1 has no prompt"))
+		Expect(library.Add(prompterizer.PromptDefinition{Name: "summarize", Version: "1", Prompt: []string{"Summarize"}, Settings: prompterizer.DefinitionSettings{Temperature: lo.ToPtr(3.0)}})).
+			To(MatchError(ContainSubstring("prompt summarize version 1: invalid prompt settings: temperature must be between 0 and 2, got 3")))
+	})
+
+	It("should return an error for unknown fields", func() {
+		err := library.Load("typo.yaml", []byte("name: summarize\nversion: \"1\"\nprompts: [Summarize]\n"))
+
+		Expect(err).To(MatchError(ContainSubstring("unable to load prompt definition typo.yaml")))
+		Expect(err).To(MatchError(ContainSubstring("field prompts not found")))
+	})
+
+	It("should return an error for missing prompts and versions", func() {
+		Expect(library.Add(prompterizer.PromptDefinition{Name: "summarize", Version: "1", Prompt: []string{"Summarize"}})).To(Succeed())
+
+		_, err := library.Get("translate", "")
+		Expect(err).To(MatchError("prompt translate not found"))
+
+		_, err = library.Get("summarize", "2")
+		Expect(err).To(MatchError("prompt summarize has no version 2"))
+	})
+})
@@ -0,0 +1,167 @@
+package prompterizer
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// promptObject is a JSON object that keeps its properties in field order
+type promptObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o promptObject) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		keyJson, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueJson, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(keyJson)
+		buffer.WriteByte(':')
+		buffer.Write(valueJson)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+func (o *promptObject) set(key string, value any) {
+	if o.values == nil {
+		o.values = map[string]any{}
+	}
+	o.keys = append(o.keys, key)
+	o.values[key] = value
+}
+
+// MarshalResponse serializes a response struct the way the model is asked to respond: keyed by the prompt
+// tag names, leaving out fields without a prompt tag, with maps as lists of key/value entries
+func MarshalResponse(v any) (string, error) {
+	value, err := marshalValue(reflect.ValueOf(v), "")
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal response: %w", err)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal response: %w", err)
+	}
+	return string(data), nil
+}
+
+// marshalValue converts a value to the shape of its schema. The prompt type is that of the enclosing field,
+// which for slices and maps is the type of the items or values.
+func marshalValue(value reflect.Value, promptType genai.Type) (any, error) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil, nil
+	}
+
+	if implementsMarshaler(value.Type()) {
+		pointer := reflect.New(value.Type())
+		pointer.Elem().Set(value)
+		data, err := json.Marshal(pointer.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return leafJSON(data, promptType), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		var object promptObject
+		for _, field := range promptFields(value.Type()) {
+			fieldValue, err := marshalValue(fieldByIndex(value, field.Field.Index), field.Params.Type)
+			if err != nil {
+				return nil, err
+			}
+			object.set(field.Params.Name, fieldValue)
+		}
+		return object, nil
+
+	case reflect.Slice, reflect.Array:
+		items := make([]any, value.Len())
+		for i := range items {
+			item, err := marshalValue(value.Index(i), promptType)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s, only string keys are supported", value.Type().Key())
+		}
+
+		keys := value.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		entries := make([]any, len(keys))
+		for i, key := range keys {
+			entryValue, err := marshalValue(value.MapIndex(key), promptType)
+			if err != nil {
+				return nil, err
+			}
+			var entry promptObject
+			entry.set(mapEntryKey, key.String())
+			entry.set(mapEntryValue, entryValue)
+			entries[i] = entry
+		}
+		return entries, nil
+	}
+
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return leafJSON(data, promptType), nil
+}
+
+// leafJSON unquotes numbers that marshal as strings, e.g. decimal.Decimal, when the schema expects a number
+func leafJSON(data []byte, promptType genai.Type) json.RawMessage {
+	if promptType != genai.TypeNumber && promptType != genai.TypeInteger {
+		return data
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return data
+	}
+	if decoded, err := decodeJSON(text); err == nil {
+		if _, ok := decoded.(json.Number); ok {
+			return json.RawMessage(text)
+		}
+	}
+	return data
+}
+
+func implementsMarshaler(t reflect.Type) bool {
+	pointerType := reflect.PointerTo(t)
+	return pointerType.Implements(jsonMarshalerType) || pointerType.Implements(textMarshalerType)
+}
@@ -0,0 +1,83 @@
+package prompterizer_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shopspring/decimal"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+type Listing struct {
+	Title      string            `json:"listing_title" prompt:"title,string"`
+	Price      *float64          `json:"price" prompt:"price,number"`
+	ListedAt   time.Time         `json:"listed_at" prompt:"listedAt,string,date-time"`
+	Tags       []string          `json:"tags" prompt:"tags,string"`
+	Attributes map[string]string `json:"attributes" prompt:"attributes,string"`
+	Internal   string            `json:"internal"`
+	ReviewSource
+}
+
+type Payment struct {
+	Amount    decimal.Decimal `json:"amount" prompt:"amount,number"`
+	Reference decimal.Decimal `json:"reference" prompt:"reference,string"`
+}
+
+var _ = Describe("MarshalResponse", func() {
+	It("should serialize a struct by its prompt names in field order", func() {
+		price := 9.5
+		listing := Listing{
+			Title:        "Lamp",
+			Price:        &price,
+			ListedAt:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			Tags:         []string{"home"},
+			Attributes:   map[string]string{"color": "red", "bulb": "E27"},
+			Internal:     "hidden",
+			ReviewSource: ReviewSource{Source: "web"},
+		}
+
+		output, err := prompterizer.MarshalResponse(listing)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal(`{"title":"Lamp","price":9.5,"listedAt":"2025-01-02T03:04:05Z","tags":["home"],` +
+			`"attributes":[{"key":"bulb","value":"E27"},{"key":"color","value":"red"}],"source":"web"}`))
+	})
+
+	It("should serialize nil pointers as null and nil slices as empty arrays", func() {
+		output, err := prompterizer.MarshalResponse(&Listing{})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal(`{"title":"","price":null,"listedAt":"0001-01-01T00:00:00Z","tags":[],"attributes":[],"source":""}`))
+	})
+
+	It("should write numbers that marshal as strings as JSON numbers where the schema expects a number", func() {
+		payment := Payment{
+			Amount:    decimal.RequireFromString("12.5"),
+			Reference: decimal.RequireFromString("1001"),
+		}
+
+		output, err := prompterizer.MarshalResponse(payment)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal(`{"amount":12.5,"reference":"1001"}`))
+
+		schema, err := prompterizer.MarshalResponseSchema(Payment{}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		violations, err := prompterizer.Validate(schema, output)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(BeEmpty())
+	})
+
+	It("should round trip through UnmarshalWithOptions by prompt tags", func() {
+		listing := Listing{Title: "Lamp", Tags: []string{"home"}, Attributes: map[string]string{"color": "red"}}
+
+		output, err := prompterizer.MarshalResponse(listing)
+		Expect(err).ToNot(HaveOccurred())
+
+		decoded, _, err := prompterizer.UnmarshalWithOptions[Listing](output, prompterizer.UnmarshalOptions{ByPromptTags: true})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(listing))
+	})
+})
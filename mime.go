@@ -0,0 +1,120 @@
+package prompterizer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/samber/lo"
+)
+
+// MIME types accepted by Gemini for inline data and file references
+var supportedMimeTypes = lo.Keyify([]string{
+	"application/pdf",
+	"application/json",
+	"image/png",
+	"image/jpeg",
+	"image/webp",
+	"image/heic",
+	"image/heif",
+	"text/plain",
+	"text/csv",
+	"text/html",
+	"text/css",
+	"text/md",
+	"text/xml",
+	"text/rtf",
+	"text/javascript",
+	"text/x-python",
+	"audio/wav",
+	"audio/mp3",
+	"audio/mpeg",
+	"audio/aiff",
+	"audio/aac",
+	"audio/ogg",
+	"audio/flac",
+	"video/mp4",
+	"video/mpeg",
+	"video/mpg",
+	"video/mov",
+	"video/avi",
+	"video/x-flv",
+	"video/webm",
+	"video/wmv",
+	"video/3gpp",
+})
+
+// Names http.DetectContentType uses for types Gemini knows under another name
+var detectedMimeTypes = map[string]string{
+	"audio/wave":      "audio/wav",
+	"audio/mpeg":      "audio/mp3",
+	"application/ogg": "audio/ogg",
+}
+
+// Audio signatures http.DetectContentType does not recognize
+var audioSignatures = []struct {
+	prefix   []byte
+	mimeType string
+}{
+	{[]byte("fLaC"), "audio/flac"},
+	{[]byte{0xFF, 0xF1}, "audio/aac"},
+	{[]byte{0xFF, 0xF9}, "audio/aac"},
+	{[]byte{0xFF, 0xFB}, "audio/mp3"},
+	{[]byte{0xFF, 0xFA}, "audio/mp3"},
+	{[]byte{0xFF, 0xF3}, "audio/mp3"},
+	{[]byte{0xFF, 0xF2}, "audio/mp3"},
+}
+
+// DetectMimeType sniffs the MIME type of file data, returning an error if it is not a type Gemini accepts
+func DetectMimeType(data []byte) (string, error) {
+	for _, signature := range audioSignatures {
+		if bytes.HasPrefix(data, signature.prefix) {
+			return signature.mimeType, nil
+		}
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "", fmt.Errorf("unable to detect the MIME type of data: %w", err)
+	}
+	if detected, ok := detectedMimeTypes[mimeType]; ok {
+		mimeType = detected
+	}
+	if mimeType == "text/plain" && looksLikeCSV(data) {
+		mimeType = "text/csv"
+	}
+
+	if err := validateMimeType(mimeType); err != nil {
+		return "", fmt.Errorf("unable to detect the MIME type of data, set it explicitly: %w", err)
+	}
+	return mimeType, nil
+}
+
+func validateMimeType(mimeType string) error {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return fmt.Errorf("invalid MIME type %s: %w", mimeType, err)
+	}
+	if _, ok := supportedMimeTypes[mediaType]; !ok {
+		return fmt.Errorf("unsupported MIME type %s", mimeType)
+	}
+	return nil
+}
+
+// looksLikeCSV reports whether the first lines of text data have the same number of commas
+func looksLikeCSV(data []byte) bool {
+	lines := bytes.Split(data[:min(len(data), 4096)], []byte("\n"))
+	if len(data) > 4096 {
+		lines = lines[:len(lines)-1] // The last line may be cut off
+	}
+	lines = lo.Filter(lines, func(line []byte, _ int) bool { return len(bytes.TrimSpace(line)) > 0 })
+	if len(lines) < 2 {
+		return false
+	}
+
+	commas := bytes.Count(lines[0], []byte(","))
+	return commas > 0 && lo.EveryBy(lines[:min(len(lines), 5)], func(line []byte) bool {
+		return bytes.Count(line, []byte(",")) == commas
+	})
+}
@@ -0,0 +1,65 @@
+package prompterizer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+var _ = Describe("DetectMimeType", func() {
+	DescribeTable("should detect common file types",
+		func(data []byte, mimeType string) {
+			detected, err := prompterizer.DetectMimeType(data)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(detected).To(Equal(mimeType))
+		},
+		Entry("PDF", []byte("%PDF-1.7\n%âãÏÓ"), "application/pdf"),
+		Entry("PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png"),
+		Entry("JPEG", []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"), "image/jpeg"),
+		Entry("WebP", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp"),
+		Entry("plain text", []byte("Payment is due within 30 days."), "text/plain"),
+		Entry("CSV", []byte("sku,name,quantity\nA-1,Nails,10\nA-2,Glue,2\n"), "text/csv"),
+		Entry("WAV", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "audio/wav"),
+		Entry("MP3 with ID3 tag", []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), "audio/mp3"),
+		Entry("MP3 frame", []byte{0xFF, 0xFB, 0x90, 0x44}, "audio/mp3"),
+		Entry("FLAC", []byte("fLaC\x00\x00\x00\x22"), "audio/flac"),
+		Entry("AAC", []byte{0xFF, 0xF1, 0x50, 0x80}, "audio/aac"),
+		Entry("Ogg", []byte("OggS\x00\x02\x00\x00"), "audio/ogg"),
+	)
+
+	It("should not mistake prose with commas for CSV", func() {
+		detected, err := prompterizer.DetectMimeType([]byte("Dear customer, thank you.\nYour order, as requested, has shipped.\n"))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(detected).To(Equal("text/plain"))
+	})
+
+	It("should return an error for unsupported types", func() {
+		_, err := prompterizer.DetectMimeType([]byte("GIF89a\x01\x00\x01\x00"))
+
+		Expect(err).To(MatchError("unable to detect the MIME type of data, set it explicitly: unsupported MIME type image/gif"))
+	})
+})
+
+var _ = Describe("FileData", func() {
+	It("should detect the MIME type instead of dropping the data", func() {
+		_, parts, _, err := prompterizer.GenerateGeminiParts(prompterizer.PromptParams{
+			FileData:       []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+			ResponseStruct: ResponseStruct{},
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts).To(HaveLen(1))
+		Expect(parts[0].InlineData.MIMEType).To(Equal("image/png"))
+	})
+
+	It("should return an error if the MIME type cannot be detected", func() {
+		_, _, _, err := prompterizer.GenerateGeminiParts(prompterizer.PromptParams{
+			FileData:       []byte{0x00, 0x01, 0x02},
+			ResponseStruct: ResponseStruct{},
+		})
+
+		Expect(err).To(MatchError(ContainSubstring("invalid attachment 0: unable to detect the MIME type of data")))
+	})
+})
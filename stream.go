@@ -0,0 +1,164 @@
+package prompterizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StreamDecoder incrementally decodes a JSON response streamed in chunks. After each chunk the text
+// received so far is cut back to the last completed value (a field, an array item or an opened
+// object/array) and closed, so partial values never contain truncated strings or numbers.
+type StreamDecoder[T any] struct {
+	options UnmarshalOptions
+	buffer  strings.Builder
+	scanner partialScanner
+}
+
+type partialFrame struct {
+	open       byte // '{' or '['
+	afterColon bool // Within an object, whether a string is a value rather than a key
+}
+
+// partialScanner tracks the JSON structure of the buffered text, one byte at a time
+type partialScanner struct {
+	start       int // Index of the top-level value, -1 until found
+	stack       []partialFrame
+	inString    bool
+	isKey       bool
+	escaped     bool
+	inPrimitive bool
+	complete    bool
+
+	cut        int    // End of the last completed value
+	cutClosers string // Brackets that close the containers open at cut
+}
+
+func NewStreamDecoder[T any](options UnmarshalOptions) *StreamDecoder[T] {
+	return &StreamDecoder[T]{
+		options: options,
+		scanner: partialScanner{start: -1},
+	}
+}
+
+// Write appends a chunk and returns the partially populated value, along with whether the chunk
+// completed any new values since the previous call.
+func (d *StreamDecoder[T]) Write(chunk string) (T, bool, error) {
+	offset := d.buffer.Len()
+	d.buffer.WriteString(chunk)
+
+	previousCut := d.scanner.cut
+	for i := 0; i < len(chunk); i++ {
+		d.scanner.feed(chunk[i], offset+i)
+	}
+	if d.scanner.cut == previousCut {
+		return *new(T), false, nil
+	}
+
+	partialOptions := d.options
+	partialOptions.Lenient = false
+	partialOptions.partial = true
+
+	partial := d.buffer.String()[d.scanner.start:d.scanner.cut] + d.scanner.cutClosers
+	value, _, err := UnmarshalWithOptions[T](partial, partialOptions)
+	if err != nil {
+		return *new(T), false, fmt.Errorf("unable to decode partial response: %w", err)
+	}
+	return value, true, nil
+}
+
+// Close unmarshals the complete response
+func (d *StreamDecoder[T]) Close() (T, error) {
+	value, _, err := UnmarshalWithOptions[T](d.buffer.String(), d.options)
+	return value, err
+}
+
+func (s *partialScanner) feed(c byte, pos int) {
+	if s.complete {
+		return
+	}
+
+	if s.start == -1 {
+		if c != '{' && c != '[' {
+			return // Skip anything before the payload, e.g. a code fence
+		}
+		s.start = pos
+	}
+
+	if s.inString {
+		switch {
+		case s.escaped:
+			s.escaped = false
+		case c == '\\':
+			s.escaped = true
+		case c == '"':
+			s.inString = false
+			if !s.isKey {
+				s.completeValue(pos + 1)
+			}
+		}
+		return
+	}
+
+	if s.inPrimitive {
+		if !strings.ContainsRune(",}] \t\r\n", rune(c)) {
+			return
+		}
+		s.inPrimitive = false
+		s.completeValue(pos)
+	}
+
+	switch c {
+	case '{', '[':
+		s.stack = append(s.stack, partialFrame{open: c})
+		s.markCut(pos + 1)
+	case '}', ']':
+		if len(s.stack) > 0 {
+			s.stack = s.stack[:len(s.stack)-1]
+		}
+		s.completeValue(pos + 1)
+	case ':':
+		if top := s.top(); top != nil {
+			top.afterColon = true
+		}
+	case '"':
+		s.inString = true
+		top := s.top()
+		s.isKey = top != nil && top.open == '{' && !top.afterColon
+	case ',', ' ', '\t', '\r', '\n':
+	default:
+		s.inPrimitive = true
+	}
+}
+
+func (s *partialScanner) completeValue(end int) {
+	if len(s.stack) == 0 {
+		s.complete = true
+		s.cut = end
+		s.cutClosers = ""
+		return
+	}
+
+	s.top().afterColon = false
+	s.markCut(end)
+}
+
+func (s *partialScanner) markCut(end int) {
+	closers := make([]byte, 0, len(s.stack))
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		if s.stack[i].open == '{' {
+			closers = append(closers, '}')
+		} else {
+			closers = append(closers, ']')
+		}
+	}
+
+	s.cut = end
+	s.cutClosers = string(closers)
+}
+
+func (s *partialScanner) top() *partialFrame {
+	if len(s.stack) == 0 {
+		return nil
+	}
+	return &s.stack[len(s.stack)-1]
+}
@@ -0,0 +1,134 @@
+package prompterizer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+type Outline struct {
+	Title    string           `json:"title" prompt:"title,string"`
+	Count    int              `json:"count" prompt:"count,integer"`
+	Sections []OutlineSection `json:"sections" prompt:"sections,object"`
+}
+
+type TaggedOutline struct {
+	Title string            `json:"title" prompt:"title,string"`
+	Tags  map[string]string `json:"tags" prompt:"tags,string"`
+}
+
+type OutlineSection struct {
+	Heading string   `json:"heading" prompt:"heading,string"`
+	Points  []string `json:"points" prompt:"points,string"`
+}
+
+var _ = Describe("StreamDecoder", func() {
+	var decoder *prompterizer.StreamDecoder[Outline]
+
+	BeforeEach(func() {
+		decoder = prompterizer.NewStreamDecoder[Outline](prompterizer.UnmarshalOptions{})
+	})
+
+	write := func(chunk string) (Outline, bool) {
+		value, progressed, err := decoder.Write(chunk)
+		Expect(err).ToNot(HaveOccurred())
+		return value, progressed
+	}
+
+	It("should only emit completed fields", func() {
+		_, progressed := write(`{"title": "Quarterly `)
+		Expect(progressed).To(BeTrue()) // The opening brace
+
+		_, progressed = write(`report`)
+		Expect(progressed).To(BeFalse())
+
+		value, progressed := write(`", "count": 1`)
+		Expect(progressed).To(BeTrue())
+		Expect(value.Title).To(Equal("Quarterly report"))
+		Expect(value.Count).To(BeZero()) // The number could still continue
+
+		value, progressed = write(`2, `)
+		Expect(progressed).To(BeTrue())
+		Expect(value.Count).To(Equal(12))
+	})
+
+	It("should emit array items as they complete", func() {
+		value, _ := write(`{"title": "Plan", "sections": [{"heading": "Intro", "points": ["a", "b`)
+		Expect(value.Sections).To(HaveLen(1))
+		Expect(value.Sections[0].Heading).To(Equal("Intro"))
+		Expect(value.Sections[0].Points).To(Equal([]string{"a"}))
+
+		value, _ = write(`\"c"]}, {"heading": "Body`)
+		Expect(value.Sections).To(HaveLen(2))
+		Expect(value.Sections[0].Points).To(Equal([]string{"a", `b"c`}))
+		Expect(value.Sections[1].Heading).To(BeEmpty())
+
+		value, _ = write(`"}]}`)
+		Expect(value.Sections[1].Heading).To(Equal("Body"))
+
+		final, err := decoder.Close()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(final).To(Equal(value))
+	})
+
+	It("should skip text before the payload", func() {
+		decoder = prompterizer.NewStreamDecoder[Outline](prompterizer.UnmarshalOptions{Lenient: true})
+
+		_, progressed := write("```json\n")
+		Expect(progressed).To(BeFalse())
+
+		value, progressed := write(`{"title": "Fenced", `)
+		Expect(progressed).To(BeTrue())
+		Expect(value.Title).To(Equal("Fenced"))
+
+		write("\"count\": 2}\n```")
+		final, err := decoder.Close()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(final.Title).To(Equal("Fenced"))
+		Expect(final.Count).To(Equal(2))
+	})
+
+	It("should apply the unmarshal options to partial values", func() {
+		decoder = prompterizer.NewStreamDecoder[Outline](prompterizer.UnmarshalOptions{Coerce: true})
+
+		value, _ := write(`{"count": "3", "title": `)
+		Expect(value.Count).To(Equal(3))
+	})
+
+	It("should stream map entries as their keys complete", func() {
+		mapDecoder := prompterizer.NewStreamDecoder[TaggedOutline](prompterizer.UnmarshalOptions{})
+		chunks := []string{`{"title": "Plan", "tags": [{`, `"key": "owner"`, `, "value": "ana"}, {"ke`, `y": "team", "value": "ops"}]}`}
+
+		var values []TaggedOutline
+		for _, chunk := range chunks {
+			value, progressed, err := mapDecoder.Write(chunk)
+			Expect(err).ToNot(HaveOccurred())
+			if progressed {
+				values = append(values, value)
+			}
+		}
+
+		Expect(values[0]).To(Equal(TaggedOutline{Title: "Plan", Tags: map[string]string{}}))
+		Expect(values[1].Tags).To(Equal(map[string]string{"owner": ""}))
+		Expect(values[2].Tags).To(Equal(map[string]string{"owner": "ana"}))
+		Expect(values[len(values)-1].Tags).To(Equal(map[string]string{"owner": "ana", "team": "ops"}))
+
+		value, err := mapDecoder.Close()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value.Tags).To(Equal(map[string]string{"owner": "ana", "team": "ops"}))
+	})
+
+	It("should return an error if a partial value cannot be decoded", func() {
+		_, _, err := decoder.Write(`{"count": "three",`)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to decode partial response"))
+	})
+
+	It("should return an error when closing an incomplete response", func() {
+		write(`{"title": "Incomplete"`)
+
+		_, err := decoder.Close()
+		Expect(err).To(HaveOccurred())
+	})
+})
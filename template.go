@@ -0,0 +1,99 @@
+package prompterizer
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/samber/lo"
+)
+
+// templateData merges the template variables with the richer template data, which takes precedence
+func templateData(params PromptParams) map[string]any {
+	data := make(map[string]any, len(params.TemplateVariables)+len(params.TemplateData))
+	for key, value := range params.TemplateVariables {
+		data[key] = value
+	}
+	for key, value := range params.TemplateData {
+		data[key] = value
+	}
+	return data
+}
+
+// renderTemplate renders text as a text/template, e.g. "Summarize {{.document}}", returning an error
+// listing every variable it refers to that is not in the data
+func renderTemplate(kind, text string, data map[string]any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(kind).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template in %s: %w", kind, err)
+	}
+
+	var missingVariables []string
+	collectMissingVariables(tmpl.Tree.Root, data, true, &missingVariables)
+	if len(missingVariables) > 0 {
+		return "", fmt.Errorf("missing variables in %s: %s", kind, strings.Join(lo.Uniq(missingVariables), ", "))
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("unable to render %s: %w", kind, err)
+	}
+	return rendered.String(), nil
+}
+
+// collectMissingVariables walks a template looking for references to top-level keys that are not in the
+// data. Inside range and with blocks dot is no longer the data, so only $ references are checked there.
+func collectMissingVariables(node parse.Node, data map[string]any, dotIsData bool, missing *[]string) {
+	checkKey := func(key string) {
+		if _, ok := data[key]; !ok {
+			*missing = append(*missing, key)
+		}
+	}
+	walkBranch := func(branch *parse.BranchNode) {
+		collectMissingVariables(branch.Pipe, data, dotIsData, missing)
+		collectMissingVariables(branch.List, data, dotIsData && branch.NodeType == parse.NodeIf, missing)
+		if branch.ElseList != nil {
+			collectMissingVariables(branch.ElseList, data, dotIsData, missing)
+		}
+	}
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			collectMissingVariables(child, data, dotIsData, missing)
+		}
+	case *parse.ActionNode:
+		collectMissingVariables(n.Pipe, data, dotIsData, missing)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			collectMissingVariables(n.Pipe, data, dotIsData, missing)
+		}
+	case *parse.PipeNode:
+		for _, command := range n.Cmds {
+			for _, arg := range command.Args {
+				collectMissingVariables(arg, data, dotIsData, missing)
+			}
+		}
+	case *parse.ChainNode:
+		collectMissingVariables(n.Node, data, dotIsData, missing)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode)
+	case *parse.FieldNode:
+		if dotIsData {
+			checkKey(n.Ident[0])
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			checkKey(n.Ident[1])
+		}
+	}
+}
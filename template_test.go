@@ -0,0 +1,82 @@
+package prompterizer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+var _ = Describe("Prompt templates", func() {
+	var params prompterizer.PromptParams
+
+	BeforeEach(func() {
+		params = prompterizer.PromptParams{
+			SystemInstructions: []string{"You review documents for {{.company}}."},
+			Prompt: []string{
+				"Check the invoice from {{.vendor}}.",
+				"Flag these line items:{{range .items}} {{.sku}} ({{$.currency}}){{end}}",
+				"Static prompt with {single} braces",
+			},
+			TemplateVariables: map[string]string{"company": "ACME", "vendor": "Globex", "currency": "USD"},
+			TemplateData: map[string]any{
+				"items": []map[string]string{{"sku": "A-1"}, {"sku": "B-2"}},
+			},
+			RenderTemplates: true,
+			ResponseStruct:  ResponseStruct{},
+		}
+	})
+
+	It("should render prompts and system instructions with the template variables and data", func() {
+		systemInstruction, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(systemInstruction.Parts[0].Text).To(Equal("You review documents for ACME."))
+		Expect(parts[0].Text).To(Equal("Check the invoice from Globex."))
+		Expect(parts[1].Text).To(Equal("Flag these line items: A-1 (USD) B-2 (USD)"))
+		Expect(parts[2].Text).To(Equal("Static prompt with {single} braces"))
+	})
+
+	It("should prefer template data over template variables", func() {
+		params.TemplateData["vendor"] = "Initech"
+
+		_, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parts[0].Text).To(Equal("Check the invoice from Initech."))
+	})
+
+	It("should return an error listing the missing variables", func() {
+		params.Prompt = []string{"{{.vendor}} {{.region}}{{if .urgent}} urgently{{end}}{{range .items}}{{.sku}} {{$.region}}{{end}}"}
+
+		_, _, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).To(MatchError("error rendering prompt 0: missing variables in prompt: region, urgent"))
+	})
+
+	It("should return an error for missing variables in system instructions", func() {
+		delete(params.TemplateVariables, "company")
+
+		_, _, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).To(MatchError("error rendering system instruction 0: missing variables in system instruction: company"))
+	})
+
+	It("should send prompts unchanged unless templates are enabled", func() {
+		params.RenderTemplates = false
+		params.Prompt = []string{`Return JSON like {{"a": 1}}`}
+
+		systemInstruction, parts, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(systemInstruction.Parts[0].Text).To(Equal("You review documents for {{.company}}."))
+		Expect(parts[0].Text).To(Equal(`Return JSON like {{"a": 1}}`))
+	})
+
+	It("should return an error for invalid templates", func() {
+		params.Prompt = []string{"{{.vendor"}
+
+		_, _, _, err := prompterizer.GenerateGeminiParts(params)
+
+		Expect(err).To(MatchError(ContainSubstring("error rendering prompt 0: invalid template in prompt")))
+	})
+})
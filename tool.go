@@ -0,0 +1,114 @@
+package prompterizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"google.golang.org/genai"
+)
+
+var toolNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]{0,63}$`)
+
+// ToolHandler is a Go function the model can call. Its arguments are a prompt tagged struct, which
+// describes the parameters the same way a response struct describes the response.
+type ToolHandler[A any] func(ctx context.Context, args A) (any, error)
+
+// ToolSet holds the tools offered to the model and dispatches the model's function calls to them
+type ToolSet struct {
+	tools map[string]*registeredTool
+	names []string
+}
+
+type registeredTool struct {
+	declaration *genai.FunctionDeclaration
+	call        func(ctx context.Context, argsJson string) (any, error)
+}
+
+func NewToolSet() *ToolSet {
+	return &ToolSet{tools: map[string]*registeredTool{}}
+}
+
+// RegisterTool adds a handler to the tool set, declaring its parameters from the prompt tags of A
+func RegisterTool[A any](tools *ToolSet, name, description string, handler ToolHandler[A]) error {
+	if !toolNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid tool name '%s'", name)
+	}
+	if _, ok := tools.tools[name]; ok {
+		return fmt.Errorf("tool %s is already registered", name)
+	}
+	if argsType := reflect.TypeFor[A](); argsType.Kind() != reflect.Struct {
+		return fmt.Errorf("arguments of tool %s must be a struct, got %s", name, argsType.Kind())
+	}
+
+	schema, err := MarshalResponseSchema(new(A), nil)
+	if err != nil {
+		return fmt.Errorf("unable to generate parameters for tool %s: %w", name, err)
+	}
+
+	declaration := &genai.FunctionDeclaration{Name: name, Description: description}
+	if len(schema.Properties) > 0 {
+		declaration.Parameters = schema
+	}
+
+	tools.tools[name] = &registeredTool{
+		declaration: declaration,
+		call: func(ctx context.Context, argsJson string) (any, error) {
+			violations, err := Validate(schema, argsJson)
+			if err != nil {
+				return nil, fmt.Errorf("invalid arguments for tool %s: %w", name, err)
+			}
+			if len(violations) > 0 {
+				return nil, fmt.Errorf("invalid arguments for tool %s: %w", name, &ValidationError{Violations: violations})
+			}
+
+			args, _, err := UnmarshalWithOptions[A](argsJson, UnmarshalOptions{ByPromptTags: true})
+			if err != nil {
+				return nil, fmt.Errorf("invalid arguments for tool %s: %w", name, err)
+			}
+
+			result, err := handler(ctx, args)
+			if err != nil {
+				return nil, fmt.Errorf("tool %s failed: %w", name, err)
+			}
+			return result, nil
+		},
+	}
+	tools.names = append(tools.names, name)
+	return nil
+}
+
+// Tool declares every registered tool, in registration order, for GenerateContentConfig.Tools
+func (t *ToolSet) Tool() *genai.Tool {
+	tool := &genai.Tool{}
+	for _, name := range t.names {
+		tool.FunctionDeclarations = append(tool.FunctionDeclarations, t.tools[name].declaration)
+	}
+	return tool
+}
+
+// Call decodes the arguments of a function call and dispatches it to its handler. The handler's result is
+// returned as the "output" of the function response, which is encoded with encoding/json.
+func (t *ToolSet) Call(ctx context.Context, call *genai.FunctionCall) (*genai.FunctionResponse, error) {
+	tool, ok := t.tools[call.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %s", call.Name)
+	}
+
+	args := call.Args
+	if args == nil {
+		args = map[string]any{}
+	}
+	argsJson, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments for tool %s: %w", call.Name, err)
+	}
+
+	result, err := tool.call(ctx, string(argsJson))
+	if err != nil {
+		return nil, err
+	}
+	return &genai.FunctionResponse{ID: call.ID, Name: call.Name, Response: map[string]any{"output": result}}, nil
+}
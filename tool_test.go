@@ -0,0 +1,111 @@
+package prompterizer_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+type LookupOrderArgs struct {
+	OrderID string   `json:"order_id" prompt:"orderId,string,required" prompt_description:"The order to look up"`
+	Detail  string   `json:"detail" prompt:"detail,string" prompt_enum:"summary,full"`
+	Fields  []string `json:"fields" prompt:"fields,string"`
+	Limit   *int     `json:"limit" prompt:"limit,integer"`
+}
+
+type NoArgs struct{}
+
+var _ = Describe("ToolSet", func() {
+	var (
+		tools    *prompterizer.ToolSet
+		received LookupOrderArgs
+	)
+
+	BeforeEach(func() {
+		tools = prompterizer.NewToolSet()
+		Expect(prompterizer.RegisterTool(tools, "lookup_order", "Looks up an order", func(ctx context.Context, args LookupOrderArgs) (any, error) {
+			received = args
+			if args.OrderID == "missing" {
+				return nil, errors.New("order not found")
+			}
+			return map[string]string{"status": "shipped"}, nil
+		})).To(Succeed())
+		Expect(prompterizer.RegisterTool(tools, "current_time", "Returns the current time", func(ctx context.Context, args NoArgs) (any, error) {
+			return "2025-01-02T03:04:05Z", nil
+		})).To(Succeed())
+	})
+
+	It("should declare the tools with parameters from the argument structs", func() {
+		tool := tools.Tool()
+
+		Expect(tool.FunctionDeclarations).To(HaveLen(2))
+		lookup := tool.FunctionDeclarations[0]
+		Expect(lookup.Name).To(Equal("lookup_order"))
+		Expect(lookup.Description).To(Equal("Looks up an order"))
+		Expect(lookup.Parameters.Type).To(Equal(genai.TypeObject))
+		Expect(lookup.Parameters.Required).To(Equal([]string{"orderId"}))
+		Expect(lookup.Parameters.Properties["orderId"].Description).To(Equal("The order to look up"))
+		Expect(lookup.Parameters.Properties["detail"].Enum).To(Equal([]string{"summary", "full"}))
+		Expect(lookup.Parameters.Properties["fields"].Items.Type).To(Equal(genai.TypeString))
+		Expect(*lookup.Parameters.Properties["limit"].Nullable).To(BeTrue())
+
+		Expect(tool.FunctionDeclarations[1].Name).To(Equal("current_time"))
+		Expect(tool.FunctionDeclarations[1].Parameters).To(BeNil())
+	})
+
+	It("should decode the arguments by prompt names and dispatch to the handler", func() {
+		response, err := tools.Call(context.Background(), &genai.FunctionCall{
+			ID:   "call-1",
+			Name: "lookup_order",
+			Args: map[string]any{"orderId": "A-1", "fields": []any{"status"}, "limit": 2},
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(received.OrderID).To(Equal("A-1"))
+		Expect(received.Fields).To(Equal([]string{"status"}))
+		Expect(*received.Limit).To(Equal(2))
+		Expect(response).To(Equal(&genai.FunctionResponse{
+			ID:       "call-1",
+			Name:     "lookup_order",
+			Response: map[string]any{"output": map[string]string{"status": "shipped"}},
+		}))
+	})
+
+	It("should dispatch calls without arguments", func() {
+		response, err := tools.Call(context.Background(), &genai.FunctionCall{Name: "current_time"})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Response).To(Equal(map[string]any{"output": "2025-01-02T03:04:05Z"}))
+	})
+
+	It("should return an error for invalid arguments", func() {
+		_, err := tools.Call(context.Background(), &genai.FunctionCall{Name: "lookup_order", Args: map[string]any{"detail": "brief"}})
+
+		Expect(err).To(MatchError("invalid arguments for tool lookup_order: response does not match schema: $.orderId: required property is missing; $.detail: value 'brief' is not one of summary, full"))
+	})
+
+	It("should return handler errors", func() {
+		_, err := tools.Call(context.Background(), &genai.FunctionCall{Name: "lookup_order", Args: map[string]any{"orderId": "missing"}})
+
+		Expect(err).To(MatchError("tool lookup_order failed: order not found"))
+	})
+
+	It("should return an error for unknown tools", func() {
+		_, err := tools.Call(context.Background(), &genai.FunctionCall{Name: "cancel_order"})
+
+		Expect(err).To(MatchError("unknown tool cancel_order"))
+	})
+
+	It("should return an error for invalid registrations", func() {
+		handler := func(ctx context.Context, args NoArgs) (any, error) { return nil, nil }
+
+		Expect(prompterizer.RegisterTool(tools, "current_time", "", handler)).To(MatchError("tool current_time is already registered"))
+		Expect(prompterizer.RegisterTool(tools, "1st tool", "", handler)).To(MatchError("invalid tool name '1st tool'"))
+		Expect(prompterizer.RegisterTool(tools, "lookup", "", func(ctx context.Context, args string) (any, error) { return nil, nil })).
+			To(MatchError("arguments of tool lookup must be a struct, got string"))
+	})
+})
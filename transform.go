@@ -1,11 +1,11 @@
 package prompterizer
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"maps"
@@ -14,6 +14,11 @@ import (
 	"google.golang.org/genai"
 )
 
+const (
+	mapEntryKey   = "key"
+	mapEntryValue = "value"
+)
+
 type FieldParams struct {
 	Name        string
 	Type        genai.Type
@@ -22,16 +27,39 @@ type FieldParams struct {
 	Description string
 	Aliases     []string
 	IsRequired  bool
+	MaxDepth    int
+	Minimum     *float64
+	Maximum     *float64
+	MinLength   *int64
+	MaxLength   *int64
+	Pattern     string
+	MinItems    *int64
+	MaxItems    *int64
 }
 
-func Unmarshal[T any](responseJson string) (T, error) {
-	out := new(T)
-	err := json.Unmarshal([]byte(responseJson), out)
-	if err != nil {
-		return *new(T), fmt.Errorf("unable to unmarshal prompt response '%s': %w", responseJson, err)
+type marshalState struct {
+	templateVariables map[string]string
+	ancestors         map[reflect.Type]int // Structs currently being marshaled, used to detect recursion
+}
+
+func (s *marshalState) recursiveStructType(fieldType reflect.Type) (reflect.Type, bool) {
+	for lo.Contains([]reflect.Kind{reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map}, fieldType.Kind()) {
+		fieldType = fieldType.Elem()
 	}
 
-	return *out, nil
+	if fieldType.Kind() != reflect.Struct || s.ancestors[fieldType] == 0 {
+		return nil, false
+	}
+	return fieldType, true
+}
+
+// expandsStruct reports whether marshaling the field type describes the struct it refers to. Structs behind
+// pointers are only expanded as objects, while slice and map items always are.
+func expandsStruct(fieldType reflect.Type, promptType genai.Type) bool {
+	for fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() != reflect.Struct || promptType == genai.TypeObject
 }
 
 func MarshalResponseSchema(v any, templateVariables map[string]string) (*genai.Schema, error) {
@@ -48,14 +76,18 @@ func MarshalResponseSchema(v any, templateVariables map[string]string) (*genai.S
 		return nil, fmt.Errorf("input value for schema generation must be a struct or slice, got %s", vType.Kind())
 	}
 
-	return marshalType(vType, toGenaiObjectOrArray(vType), templateVariables)
+	state := &marshalState{
+		templateVariables: templateVariables,
+		ancestors:         map[reflect.Type]int{},
+	}
+	return marshalType(vType, toGenaiObjectOrArray(vType), state)
 }
 
-func marshalType(currentType reflect.Type, promptType genai.Type, templateVariables map[string]string) (*genai.Schema, error) {
+func marshalType(currentType reflect.Type, promptType genai.Type, state *marshalState) (*genai.Schema, error) {
 	switch currentType.Kind() {
 	case reflect.Pointer:
 		elementType := currentType.Elem()
-		schema, err := marshalType(elementType, promptType, templateVariables)
+		schema, err := marshalType(elementType, promptType, state)
 		if err != nil {
 			return nil, err
 		}
@@ -74,6 +106,9 @@ func marshalType(currentType reflect.Type, promptType genai.Type, templateVariab
 			Properties: map[string]*genai.Schema{},
 		}
 
+		state.ancestors[currentType]++
+		defer func() { state.ancestors[currentType]-- }()
+
 		for i := 0; i < currentType.NumField(); i++ {
 			field := currentType.Field(i)
 			if !field.IsExported() { // Skip unexported fields
@@ -82,7 +117,11 @@ func marshalType(currentType reflect.Type, promptType genai.Type, templateVariab
 
 			// Handle embedded structs
 			if field.Anonymous {
-				embeddedSchema, err := marshalType(field.Type, genai.TypeObject, templateVariables)
+				if recursiveType, ok := state.recursiveStructType(field.Type); ok {
+					return nil, fmt.Errorf("recursive type %s embedded in field %s", recursiveType.String(), field.Name)
+				}
+
+				embeddedSchema, err := marshalType(field.Type, genai.TypeObject, state)
 				if err != nil {
 					return nil, fmt.Errorf("error marshaling embedded field %s: %w", field.Name, err)
 				}
@@ -102,28 +141,41 @@ func marshalType(currentType reflect.Type, promptType genai.Type, templateVariab
 				continue
 			}
 
-			fieldSchema, err := marshalType(field.Type, fieldParams.Type, templateVariables)
+			// Recursive types are expanded up to the depth set on the field that closes the cycle,
+			// after which the field is left out of the schema. A struct field with a scalar prompt type
+			// is not expanded, so it cannot recurse.
+			if recursiveType, ok := state.recursiveStructType(field.Type); ok && expandsStruct(field.Type, fieldParams.Type) {
+				if fieldParams.MaxDepth == 0 {
+					return nil, fmt.Errorf("recursive type %s detected at field %s, set prompt_max_depth to bound its expansion", recursiveType.String(), field.Name)
+				}
+				if state.ancestors[recursiveType] >= fieldParams.MaxDepth {
+					continue
+				}
+			}
+
+			fieldSchema, err := marshalType(field.Type, fieldParams.Type, state)
 			if err != nil {
 				return nil, fmt.Errorf("error marshaling property %s (Go field %s, type %s): %w", fieldParams.Name, field.Name, field.Type.String(), err)
 			}
 
-			if err := validateMarshaledFieldType(fieldSchema, fieldParams); err != nil {
+			if err := validateMarshaledFieldType(field.Type, fieldSchema, fieldParams); err != nil {
 				return nil, err
 			}
 
-			description, err := renderDescription(fieldParams, templateVariables)
+			description, err := renderDescription(fieldParams, state.templateVariables)
 			if err != nil {
 				return nil, fmt.Errorf("error rendering description for %s: %w", fieldParams.Name, err)
 			}
 			fieldSchema.Description = description
 
-			enum, err := renderEnum(fieldParams, templateVariables)
+			enum, err := renderEnum(fieldParams, state.templateVariables)
 			if err != nil {
 				return nil, fmt.Errorf("error rendering enum for %s: %w", fieldParams.Name, err)
 			}
 			fieldSchema.Enum = enum
 
 			fieldSchema.Format = lo.FromPtr(fieldParams.Format)
+			applyFieldConstraints(field.Type, fieldSchema, fieldParams)
 
 			schema.Properties[fieldParams.Name] = fieldSchema
 			if fieldParams.IsRequired {
@@ -131,6 +183,15 @@ func marshalType(currentType reflect.Type, promptType genai.Type, templateVariab
 			}
 		}
 
+		// An alias matching another property would move that property's value onto the aliased field
+		for _, field := range promptFields(currentType) {
+			for _, alias := range field.Params.Aliases {
+				if _, ok := schema.Properties[alias]; ok {
+					return nil, fmt.Errorf("alias '%s' of property %s is also the name of a property", alias, field.Params.Name)
+				}
+			}
+		}
+
 		if len(schema.Required) > 0 {
 			schema.Required = lo.Uniq(schema.Required)
 		}
@@ -139,11 +200,41 @@ func marshalType(currentType reflect.Type, promptType genai.Type, templateVariab
 	case reflect.Slice, reflect.Array:
 		elemType := currentType.Elem()
 
-		itemsSchema, err := marshalType(elemType, toGenaiObjectOrArray(elemType), templateVariables)
+		itemsSchema, err := marshalType(elemType, toGenaiObjectOrArray(elemType), state)
 		if err != nil {
 			return nil, fmt.Errorf("error marshaling array/slice items of type %s: %w", elemType.String(), err)
 		}
-		return &genai.Schema{Type: genai.TypeArray, Items: itemsSchema}, nil
+		schema := &genai.Schema{Type: genai.TypeArray, Items: itemsSchema}
+		if currentType.Kind() == reflect.Array {
+			schema.MinItems = lo.ToPtr(int64(currentType.Len()))
+			schema.MaxItems = lo.ToPtr(int64(currentType.Len()))
+		}
+		return schema, nil
+
+	// Maps are represented as an array of key/value entries since the schema has no notion of
+	// dynamic property names. Unmarshal converts the entries back into a map.
+	case reflect.Map:
+		if currentType.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s, only string keys are supported", currentType.Key().String())
+		}
+
+		valueType := currentType.Elem()
+		valueSchema, err := marshalType(valueType, toGenaiObjectOrArray(valueType), state)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling map values of type %s: %w", valueType.String(), err)
+		}
+		return &genai.Schema{
+			Type: genai.TypeArray,
+			Items: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					mapEntryKey:   {Type: genai.TypeString},
+					mapEntryValue: valueSchema,
+				},
+				PropertyOrdering: []string{mapEntryKey, mapEntryValue},
+				Required:         []string{mapEntryKey, mapEntryValue},
+			},
+		}, nil
 
 	// Primitives
 	case reflect.String:
@@ -161,20 +252,66 @@ func marshalType(currentType reflect.Type, promptType genai.Type, templateVariab
 	}
 }
 
-func validateMarshaledFieldType(marshaledFieldSchema *genai.Schema, promptFieldParams *FieldParams) error {
-	if marshaledFieldSchema.Type == genai.TypeArray {
-		return validateMarshaledFieldType(marshaledFieldSchema.Items, promptFieldParams)
+func validateMarshaledFieldType(fieldType reflect.Type, marshaledFieldSchema *genai.Schema, promptFieldParams *FieldParams) error {
+	leafSchema := leafFieldSchema(fieldType, marshaledFieldSchema)
+
+	if leafSchema.Type == genai.TypeUnspecified || leafSchema.Type != promptFieldParams.Type {
+		return fmt.Errorf(
+			"type mismatch for field '%s': Go type implies %s, but prompt tag specifies %s",
+			promptFieldParams.Name, leafSchema.Type, promptFieldParams.Type,
+		)
 	}
 
-	if marshaledFieldSchema.Type != genai.TypeUnspecified &&
-		marshaledFieldSchema.Type == promptFieldParams.Type {
-		return nil
+	isNumeric := leafSchema.Type == genai.TypeNumber || leafSchema.Type == genai.TypeInteger
+	if (promptFieldParams.Minimum != nil || promptFieldParams.Maximum != nil) && !isNumeric {
+		return fmt.Errorf("prompt_min and prompt_max are only supported on number or integer fields, but '%s' is %s", promptFieldParams.Name, leafSchema.Type)
 	}
 
-	return fmt.Errorf(
-		"type mismatch for field '%s': Go type implies %s, but prompt tag specifies %s",
-		promptFieldParams.Name, marshaledFieldSchema.Type, promptFieldParams.Type,
-	)
+	if promptFieldParams.MinItems != nil || promptFieldParams.MaxItems != nil {
+		if marshaledFieldSchema.Type != genai.TypeArray {
+			return fmt.Errorf("prompt_min_items and prompt_max_items are only supported on slice or map fields, but '%s' is %s", promptFieldParams.Name, marshaledFieldSchema.Type)
+		}
+		if marshaledFieldSchema.MinItems != nil {
+			return fmt.Errorf("prompt_min_items and prompt_max_items cannot be used on '%s' since fixed-size arrays already set the number of items", promptFieldParams.Name)
+		}
+	}
+
+	hasStringConstraints := promptFieldParams.MinLength != nil || promptFieldParams.MaxLength != nil || promptFieldParams.Pattern != ""
+	if hasStringConstraints && leafSchema.Type != genai.TypeString {
+		return fmt.Errorf("prompt_min_length, prompt_max_length and prompt_pattern are only supported on string fields, but '%s' is %s", promptFieldParams.Name, leafSchema.Type)
+	}
+
+	return nil
+}
+
+// leafFieldSchema descends through pointers, slices and maps to the schema the prompt tag type describes
+func leafFieldSchema(fieldType reflect.Type, marshaledFieldSchema *genai.Schema) *genai.Schema {
+	switch fieldType.Kind() {
+	case reflect.Pointer:
+		return leafFieldSchema(fieldType.Elem(), marshaledFieldSchema)
+	case reflect.Slice, reflect.Array:
+		return leafFieldSchema(fieldType.Elem(), marshaledFieldSchema.Items)
+	case reflect.Map:
+		return leafFieldSchema(fieldType.Elem(), marshaledFieldSchema.Items.Properties[mapEntryValue])
+	default:
+		return marshaledFieldSchema
+	}
+}
+
+func applyFieldConstraints(fieldType reflect.Type, marshaledFieldSchema *genai.Schema, promptFieldParams *FieldParams) {
+	if promptFieldParams.MinItems != nil {
+		marshaledFieldSchema.MinItems = promptFieldParams.MinItems
+	}
+	if promptFieldParams.MaxItems != nil {
+		marshaledFieldSchema.MaxItems = promptFieldParams.MaxItems
+	}
+
+	leafSchema := leafFieldSchema(fieldType, marshaledFieldSchema)
+	leafSchema.Minimum = promptFieldParams.Minimum
+	leafSchema.Maximum = promptFieldParams.Maximum
+	leafSchema.MinLength = promptFieldParams.MinLength
+	leafSchema.MaxLength = promptFieldParams.MaxLength
+	leafSchema.Pattern = promptFieldParams.Pattern
 }
 
 func parseFieldParams(tag reflect.StructTag) (*FieldParams, error) {
@@ -214,6 +351,18 @@ func parseFieldParams(tag reflect.StructTag) (*FieldParams, error) {
 		Description: tag.Get("prompt_description"),
 	}
 
+	if maxDepth := tag.Get("prompt_max_depth"); maxDepth != "" {
+		depth, err := strconv.Atoi(maxDepth)
+		if err != nil || depth < 1 {
+			return nil, fmt.Errorf("prompt_max_depth must be a positive integer, got '%s'", maxDepth)
+		}
+		fieldParams.MaxDepth = depth
+	}
+
+	if err := parseConstraintTags(tag, fieldParams); err != nil {
+		return nil, err
+	}
+
 	switch {
 	case explicitFormat != "":
 		fieldParams.Format = &explicitFormat
@@ -226,6 +375,74 @@ func parseFieldParams(tag reflect.StructTag) (*FieldParams, error) {
 	return fieldParams, nil
 }
 
+func parseConstraintTags(tag reflect.StructTag, fieldParams *FieldParams) error {
+	var err error
+	if fieldParams.Minimum, err = parseFloatTag(tag, "prompt_min"); err != nil {
+		return err
+	}
+	if fieldParams.Maximum, err = parseFloatTag(tag, "prompt_max"); err != nil {
+		return err
+	}
+	if fieldParams.MinLength, err = parseNonNegativeIntTag(tag, "prompt_min_length"); err != nil {
+		return err
+	}
+	if fieldParams.MaxLength, err = parseNonNegativeIntTag(tag, "prompt_max_length"); err != nil {
+		return err
+	}
+
+	if fieldParams.Minimum != nil && fieldParams.Maximum != nil && *fieldParams.Minimum > *fieldParams.Maximum {
+		return fmt.Errorf("prompt_min %v is greater than prompt_max %v", *fieldParams.Minimum, *fieldParams.Maximum)
+	}
+	if fieldParams.MinItems, err = parseNonNegativeIntTag(tag, "prompt_min_items"); err != nil {
+		return err
+	}
+	if fieldParams.MaxItems, err = parseNonNegativeIntTag(tag, "prompt_max_items"); err != nil {
+		return err
+	}
+
+	if fieldParams.MinLength != nil && fieldParams.MaxLength != nil && *fieldParams.MinLength > *fieldParams.MaxLength {
+		return fmt.Errorf("prompt_min_length %d is greater than prompt_max_length %d", *fieldParams.MinLength, *fieldParams.MaxLength)
+	}
+	if fieldParams.MinItems != nil && fieldParams.MaxItems != nil && *fieldParams.MinItems > *fieldParams.MaxItems {
+		return fmt.Errorf("prompt_min_items %d is greater than prompt_max_items %d", *fieldParams.MinItems, *fieldParams.MaxItems)
+	}
+
+	if pattern := tag.Get("prompt_pattern"); pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid prompt_pattern '%s': %w", pattern, err)
+		}
+		fieldParams.Pattern = pattern
+	}
+
+	return nil
+}
+
+func parseFloatTag(tag reflect.StructTag, key string) (*float64, error) {
+	value := tag.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number, got '%s'", key, value)
+	}
+	return &parsed, nil
+}
+
+func parseNonNegativeIntTag(tag reflect.StructTag, key string) (*int64, error) {
+	value := tag.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer, got '%s'", key, value)
+	}
+	return &parsed, nil
+}
+
 func toGenaiType(promptFieldType string) (genai.Type, error) {
 	switch promptFieldType {
 	case "string":
@@ -41,30 +41,111 @@ var (
 
 type TestPrompt struct {
 	unexported     string
-	Ignored        string          `json:"ignored"`
-	DocumentDate   time.Time       `json:"documentDate" prompt:"documentDate,string,required"`
-	CreationDate   time.Time       `json:"creationDate" prompt:"creationDate,string,date-time,required"`
-	PublishDate    string          `json:"publishDate" prompt:"publishDate,string" prompt_description:"The date the document was published"`
-	Title          string          `json:"title" prompt:"title,string" prompt_description:"The title of the document under the series {seriesName}"`
-	FirstName      string          `json:"firstName" prompt:"firstName,string" prompt_aliases:"givenName"`
-	LastName       string          `json:"lastName" prompt:"lastName,string" prompt_aliases:"surName,familyName"`
-	Witness        string          `json:"witness" prompt:"witness,string" prompt_description:"The person from {seriesName} who witnessed the document signing." prompt_aliases:"witnessName,witnessedBy"`
-	IsParsed       bool            `json:"isParsed" prompt:"isParsed,bool"`
-	Count          int             `json:"count" prompt:"count,integer"`
-	Status         string          `json:"status" prompt:"status,string" prompt_enum:"active,inactive,pending"`
-	StatusCode     int             `json:"statusCode" prompt:"statusCode,integer,httpStatus" prompt_enum:"200,400,500" prompt_description:"HTTP status code for the document"`
-	TemplatedEnum  TemplateEnum    `json:"templatedEnum" prompt:"templatedEnum,string" prompt_enum:"{dynamicEnumValues}"`
-	Percentage     float64         `json:"percentage" prompt:"percentage,number"`
-	Amount         decimal.Decimal `json:"amount" prompt:"amount,number"`
-	Metadata       Metadata        `json:"metadata" prompt:"metadata,object"`
-	Tags           []string        `json:"tags" prompt:"tags,string"`
-	Events         []Event         `json:"events" prompt:"events,object"`
-	SpecialEvent   *Event          `json:"specialEvent" prompt:"specialEvent,object"`
-	OptionalEvents []*Event        `json:"optionalEvents" prompt:"optionalEvents,object"`
-	TagSets        [][]string      `json:"tagSets" prompt:"tagSets,string"`
+	Ignored        string            `json:"ignored"`
+	DocumentDate   time.Time         `json:"documentDate" prompt:"documentDate,string,required"`
+	CreationDate   time.Time         `json:"creationDate" prompt:"creationDate,string,date-time,required"`
+	PublishDate    string            `json:"publishDate" prompt:"publishDate,string" prompt_description:"The date the document was published"`
+	Title          string            `json:"title" prompt:"title,string" prompt_description:"The title of the document under the series {seriesName}"`
+	FirstName      string            `json:"firstName" prompt:"firstName,string" prompt_aliases:"givenName"`
+	LastName       string            `json:"lastName" prompt:"lastName,string" prompt_aliases:"surName,familyName"`
+	Witness        string            `json:"witness" prompt:"witness,string" prompt_description:"The person from {seriesName} who witnessed the document signing." prompt_aliases:"witnessName,witnessedBy"`
+	IsParsed       bool              `json:"isParsed" prompt:"isParsed,bool"`
+	Count          int               `json:"count" prompt:"count,integer"`
+	Status         string            `json:"status" prompt:"status,string" prompt_enum:"active,inactive,pending"`
+	StatusCode     int               `json:"statusCode" prompt:"statusCode,integer,httpStatus" prompt_enum:"200,400,500" prompt_description:"HTTP status code for the document"`
+	TemplatedEnum  TemplateEnum      `json:"templatedEnum" prompt:"templatedEnum,string" prompt_enum:"{dynamicEnumValues}"`
+	Percentage     float64           `json:"percentage" prompt:"percentage,number"`
+	Amount         decimal.Decimal   `json:"amount" prompt:"amount,number"`
+	Metadata       Metadata          `json:"metadata" prompt:"metadata,object"`
+	Tags           []string          `json:"tags" prompt:"tags,string"`
+	Events         []Event           `json:"events" prompt:"events,object"`
+	SpecialEvent   *Event            `json:"specialEvent" prompt:"specialEvent,object"`
+	OptionalEvents []*Event          `json:"optionalEvents" prompt:"optionalEvents,object"`
+	TagSets        [][]string        `json:"tagSets" prompt:"tagSets,string"`
+	Attributes     map[string]string `json:"attributes" prompt:"attributes,string"`
+	EventsByDay    map[string]Event  `json:"eventsByDay" prompt:"eventsByDay,object"`
 	Embedded
 }
 
+type Constrained struct {
+	Score    float64  `json:"score" prompt:"score,number" prompt_min:"0" prompt_max:"1.5"`
+	Quantity *int     `json:"quantity" prompt:"quantity,integer" prompt_min:"1"`
+	Code     string   `json:"code" prompt:"code,string" prompt_min_length:"3" prompt_max_length:"8" prompt_pattern:"^[A-Z]+-[0-9]+$"`
+	Keywords []string `json:"keywords" prompt:"keywords,string" prompt_max_length:"20"`
+}
+
+type Cardinality struct {
+	Keywords   []string          `json:"keywords" prompt:"keywords,string" prompt_min_items:"3" prompt_max_items:"3"`
+	Highlights *[]Event          `json:"highlights" prompt:"highlights,object" prompt_max_items:"5"`
+	Labels     map[string]string `json:"labels" prompt:"labels,string" prompt_min_items:"1"`
+	Triple     [3]int            `json:"triple" prompt:"triple,integer"`
+	Grid       [2][4]bool        `json:"grid" prompt:"grid,bool"`
+}
+
+type ItemsOnString struct {
+	Field string `json:"field" prompt:"field,string" prompt_min_items:"1"`
+}
+
+type ItemsOnFixedArray struct {
+	Field [2]string `json:"field" prompt:"field,string" prompt_max_items:"3"`
+}
+
+type MinItemsGreaterThanMaxItems struct {
+	Field []string `json:"field" prompt:"field,string" prompt_min_items:"4" prompt_max_items:"2"`
+}
+
+type MinOnString struct {
+	Field string `json:"field" prompt:"field,string" prompt_min:"1"`
+}
+
+type LengthOnInteger struct {
+	Field int `json:"field" prompt:"field,integer" prompt_max_length:"2"`
+}
+
+type InvalidMinimum struct {
+	Field int `json:"field" prompt:"field,integer" prompt_min:"one"`
+}
+
+type InvalidLength struct {
+	Field string `json:"field" prompt:"field,string" prompt_min_length:"-1"`
+}
+
+type MinGreaterThanMax struct {
+	Field int `json:"field" prompt:"field,integer" prompt_min:"10" prompt_max:"1"`
+}
+
+type InvalidPattern struct {
+	Field string `json:"field" prompt:"field,string" prompt_pattern:"[a-z"`
+}
+
+type OutlineNode struct {
+	Title    string         `json:"title" prompt:"title,string,required"`
+	Children []*OutlineNode `json:"children" prompt:"children,object" prompt_max_depth:"3"`
+}
+
+type FolderNode struct {
+	Name   string      `json:"name" prompt:"name,string"`
+	Parent *FolderNode `json:"parent" prompt:"parent,string"`
+}
+
+type OrgChart struct {
+	Root Employee `json:"root" prompt:"root,object"`
+}
+
+type Employee struct {
+	Name    string     `json:"name" prompt:"name,string"`
+	Reports []Employee `json:"reports" prompt:"reports,object"`
+}
+
+type RecursiveEmbedded struct {
+	Name string `json:"name" prompt:"name,string"`
+	*RecursiveEmbedded
+}
+
+type InvalidMaxDepth struct {
+	Field string `json:"field" prompt:"field,string" prompt_max_depth:"0"`
+}
+
 type InvalidType struct {
 	Field string `json:"invalidField" prompt:"invalidField,"`
 }
@@ -77,6 +158,10 @@ type WrongNumberOfParams struct {
 	Field string `json:"wrongNumberOfParams" prompt:"wrongNumberOfParams"`
 }
 
+type UnsupportedMapKey struct {
+	Field map[int]string `json:"unsupportedMapKey" prompt:"unsupportedMapKey,string"`
+}
+
 type UnsupportedType struct {
 	Field complex64 `json:"unsupportedField" prompt:"unsupportedField,integer"`
 }
@@ -307,6 +392,38 @@ var _ = Describe("Transform", func() {
 				})))
 			})
 
+			It("should marshal a map as an array of key/value entries", func() {
+				Expect(schema.Properties).To(HaveKey("attributes"))
+				Expect(schema.Properties["attributes"]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Type": Equal(genai.TypeArray),
+					"Items": PointTo(MatchFields(IgnoreExtras, Fields{
+						"Type":     Equal(genai.TypeObject),
+						"Required": ConsistOf("key", "value"),
+						"Properties": MatchAllKeys(Keys{
+							"key": PointTo(MatchFields(IgnoreExtras, Fields{
+								"Type": Equal(genai.TypeString),
+							})),
+							"value": PointTo(MatchFields(IgnoreExtras, Fields{
+								"Type": Equal(genai.TypeString),
+							})),
+						}),
+					})),
+				})))
+			})
+
+			It("should marshal a map of objects", func() {
+				Expect(schema.Properties).To(HaveKey("eventsByDay"))
+				Expect(schema.Properties["eventsByDay"].Items.Properties["value"]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Type": Equal(genai.TypeObject),
+					"Properties": MatchAllKeys(Keys{
+						"name": PointTo(MatchFields(IgnoreExtras, Fields{
+							"Type":        Equal(genai.TypeString),
+							"Description": Equal("The name of the event"),
+						})),
+					}),
+				})))
+			})
+
 			It("should marshal an embedded struct's fields", func() {
 				Expect(schema.Required).To(ContainElement("embeddedField"))
 				Expect(schema.Properties).To(HaveKey("embeddedField"))
@@ -316,6 +433,163 @@ var _ = Describe("Transform", func() {
 			})
 		})
 
+		Context("constraints", func() {
+			var constrainedSchema *genai.Schema
+
+			BeforeEach(func() {
+				var err error
+				constrainedSchema, err = prompterizer.MarshalResponseSchema(Constrained{}, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should marshal minimum and maximum on a number property", func() {
+				Expect(constrainedSchema.Properties["score"]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Minimum": PointTo(BeNumerically("==", 0)),
+					"Maximum": PointTo(BeNumerically("==", 1.5)),
+				})))
+			})
+
+			It("should marshal a minimum on a nullable integer property", func() {
+				Expect(constrainedSchema.Properties["quantity"]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Nullable": PointTo(BeTrue()),
+					"Minimum":  PointTo(BeNumerically("==", 1)),
+					"Maximum":  BeNil(),
+				})))
+			})
+
+			It("should marshal length and pattern constraints on a string property", func() {
+				Expect(constrainedSchema.Properties["code"]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"MinLength": PointTo(BeNumerically("==", 3)),
+					"MaxLength": PointTo(BeNumerically("==", 8)),
+					"Pattern":   Equal("^[A-Z]+-[0-9]+$"),
+				})))
+			})
+
+			It("should marshal string constraints on the items of an array", func() {
+				Expect(constrainedSchema.Properties["keywords"].MaxLength).To(BeNil())
+				Expect(constrainedSchema.Properties["keywords"].Items.MaxLength).To(PointTo(BeNumerically("==", 20)))
+			})
+
+			DescribeTable("should return an error for invalid constraints",
+				func(v any, message string) {
+					_, err := prompterizer.MarshalResponseSchema(v, map[string]string{})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(message))
+				},
+				Entry("min on a string", MinOnString{}, "prompt_min and prompt_max are only supported on number or integer fields, but 'field' is STRING"),
+				Entry("length on an integer", LengthOnInteger{}, "prompt_min_length, prompt_max_length and prompt_pattern are only supported on string fields, but 'field' is INTEGER"),
+				Entry("a non-numeric minimum", InvalidMinimum{}, "prompt_min must be a number, got 'one'"),
+				Entry("a negative length", InvalidLength{}, "prompt_min_length must be a non-negative integer, got '-1'"),
+				Entry("min greater than max", MinGreaterThanMax{}, "prompt_min 10 is greater than prompt_max 1"),
+				Entry("an invalid pattern", InvalidPattern{}, "invalid prompt_pattern '[a-z'"),
+			)
+		})
+
+		Context("cardinality", func() {
+			var cardinalitySchema *genai.Schema
+
+			BeforeEach(func() {
+				var err error
+				cardinalitySchema, err = prompterizer.MarshalResponseSchema(Cardinality{}, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should marshal min and max items on a slice property", func() {
+				Expect(cardinalitySchema.Properties["keywords"]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Type":     Equal(genai.TypeArray),
+					"MinItems": PointTo(BeNumerically("==", 3)),
+					"MaxItems": PointTo(BeNumerically("==", 3)),
+				})))
+			})
+
+			It("should marshal max items on a pointer to a slice", func() {
+				Expect(cardinalitySchema.Properties["highlights"]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Nullable": PointTo(BeTrue()),
+					"MinItems": BeNil(),
+					"MaxItems": PointTo(BeNumerically("==", 5)),
+				})))
+			})
+
+			It("should marshal min items on a map property", func() {
+				Expect(cardinalitySchema.Properties["labels"].MinItems).To(PointTo(BeNumerically("==", 1)))
+			})
+
+			It("should marshal a fixed-size array with an exact number of items", func() {
+				Expect(cardinalitySchema.Properties["triple"]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Type":     Equal(genai.TypeArray),
+					"MinItems": PointTo(BeNumerically("==", 3)),
+					"MaxItems": PointTo(BeNumerically("==", 3)),
+				})))
+			})
+
+			It("should marshal nested fixed-size arrays", func() {
+				grid := cardinalitySchema.Properties["grid"]
+				Expect(*grid.MinItems).To(BeNumerically("==", 2))
+				Expect(*grid.Items.MinItems).To(BeNumerically("==", 4))
+				Expect(*grid.Items.MaxItems).To(BeNumerically("==", 4))
+				Expect(grid.Items.Items.Type).To(Equal(genai.TypeBoolean))
+			})
+
+			DescribeTable("should return an error for invalid cardinality",
+				func(v any, message string) {
+					_, err := prompterizer.MarshalResponseSchema(v, map[string]string{})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(message))
+				},
+				Entry("items on a string", ItemsOnString{}, "prompt_min_items and prompt_max_items are only supported on slice or map fields, but 'field' is STRING"),
+				Entry("items on a fixed-size array", ItemsOnFixedArray{}, "prompt_min_items and prompt_max_items cannot be used on 'field' since fixed-size arrays already set the number of items"),
+				Entry("min items greater than max items", MinItemsGreaterThanMaxItems{}, "prompt_min_items 4 is greater than prompt_max_items 2"),
+			)
+		})
+
+		Context("recursive types", func() {
+			It("should expand a recursive type up to the max depth", func() {
+				schema, err := prompterizer.MarshalResponseSchema(OutlineNode{}, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+
+				level2 := schema.Properties["children"].Items
+				Expect(level2).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Type":     Equal(genai.TypeObject),
+					"Nullable": PointTo(BeTrue()),
+					"Required": ConsistOf("title"),
+				})))
+
+				level3 := level2.Properties["children"].Items
+				Expect(level3.Properties).To(HaveKey("title"))
+				Expect(level3.Properties).ToNot(HaveKey("children"))
+			})
+
+			It("should expand a recursive slice field of a pointer", func() {
+				schema, err := prompterizer.MarshalResponseSchema(&[]OutlineNode{}, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(schema.Items.Properties["children"].Items.Properties["children"].Items.Properties).ToNot(HaveKey("children"))
+			})
+
+			It("should not expand a recursive field with a scalar type", func() {
+				schema, err := prompterizer.MarshalResponseSchema(FolderNode{}, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(schema.Properties["parent"].Type).To(Equal(genai.TypeString))
+			})
+
+			It("should return an error for a recursive type without a max depth", func() {
+				_, err := prompterizer.MarshalResponseSchema(OrgChart{}, map[string]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("recursive type prompterizer_test.Employee detected at field Reports, set prompt_max_depth to bound its expansion"))
+			})
+
+			It("should return an error for a recursive embedded type", func() {
+				_, err := prompterizer.MarshalResponseSchema(RecursiveEmbedded{}, map[string]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("recursive type prompterizer_test.RecursiveEmbedded embedded in field RecursiveEmbedded"))
+			})
+
+			It("should return an error for an invalid max depth", func() {
+				_, err := prompterizer.MarshalResponseSchema(InvalidMaxDepth{}, map[string]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("prompt_max_depth must be a positive integer, got '0'"))
+			})
+		})
+
 		Context("errors", func() {
 			It("should return an error if the value is nil", func() {
 				_, err := prompterizer.MarshalResponseSchema(nil, map[string]string{})
@@ -353,6 +627,17 @@ var _ = Describe("Transform", func() {
 				Expect(err.Error()).To(ContainSubstring("error marshaling property unsupportedField (Go field Field, type complex64): unsupported type kind for schema generation: complex64 (Go type: complex64)"))
 			})
 
+			It("should return an error for a map without string keys", func() {
+				_, err := prompterizer.MarshalResponseSchema(UnsupportedMapKey{}, map[string]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("unsupported map key type int, only string keys are supported"))
+			})
+
+			It("should return an error for an alias that is the name of another property", func() {
+				_, err := prompterizer.MarshalResponseSchema(CollidingAlias{}, map[string]string{})
+				Expect(err).To(MatchError("alias 'y' of property x is also the name of a property"))
+			})
+
 			It("should return an error if a template variable for an enum is missing", func() {
 				_, err := prompterizer.MarshalResponseSchema(TestPrompt{}, map[string]string{"seriesName": "Business 101"})
 				Expect(err).To(HaveOccurred())
@@ -0,0 +1,350 @@
+package prompterizer
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	"google.golang.org/genai"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+type promptField struct {
+	Field  reflect.StructField
+	Params *FieldParams
+}
+
+type UnmarshalOptions struct {
+	IgnoreAliases bool // Only accept the canonical prompt names as keys
+	ByPromptTags  bool // Populate fields by their prompt tag names rather than their json tags
+	Lenient       bool // Extract and repair the JSON payload from messy model output, see ExtractJSON
+	Coerce        bool // Convert loosely-typed values (e.g. "42" for an integer) to the type the schema expects
+
+	// Used to build the schema that guides coercion
+	TemplateVariables map[string]string
+
+	partial bool // Decoding a truncated stream, where map entries may not have their key yet
+}
+
+type UnmarshalReport struct {
+	Aliases   []AliasMatch
+	Repairs   []Repair
+	Coercions []Coercion
+}
+
+type AliasMatch struct {
+	Path  string // JSON path of the canonical property
+	Alias string
+}
+
+type normalizer struct {
+	options UnmarshalOptions
+	report  *UnmarshalReport
+	errs    []error
+
+	keepSchemaShape bool // Keep prompt names as keys and maps as key/value entries, for validation
+}
+
+func Unmarshal[T any](responseJson string) (T, error) {
+	out, _, err := UnmarshalWithOptions[T](responseJson, UnmarshalOptions{})
+	return out, err
+}
+
+func UnmarshalWithOptions[T any](responseJson string, options UnmarshalOptions) (T, *UnmarshalReport, error) {
+	return decodeResponse[T](responseJson, options, nil)
+}
+
+// decodeResponse unmarshals a response, validating it against the schema first if one is given. Validation
+// runs after the response is repaired, aliases are resolved and values are coerced, so that none of these
+// are reported as violations. The schema also guides coercion.
+func decodeResponse[T any](responseJson string, options UnmarshalOptions, schema *genai.Schema) (T, *UnmarshalReport, error) {
+	report := &UnmarshalReport{}
+
+	payload := responseJson
+	if options.Lenient {
+		var err error
+		payload, report.Repairs, err = ExtractJSON(responseJson)
+		if err != nil {
+			return *new(T), report, fmt.Errorf("unable to unmarshal prompt response '%s': %w", responseJson, err)
+		}
+	}
+
+	decoded, err := decodeJSON(payload)
+	if err != nil {
+		return *new(T), report, fmt.Errorf("unable to unmarshal prompt response '%s': %w", responseJson, err)
+	}
+
+	var coercionSchema *genai.Schema
+	if options.Coerce {
+		coercionSchema = schema
+		if coercionSchema == nil {
+			coercionSchema, err = MarshalResponseSchema(new(T), options.TemplateVariables)
+			if err != nil {
+				return *new(T), report, fmt.Errorf("unable to generate schema for coercion: %w", err)
+			}
+		}
+	}
+
+	responseType := reflect.TypeFor[T]()
+	if schema != nil {
+		// Normalize without changing the shape of the response, validate, then finish normalizing
+		n := &normalizer{options: options, report: report, keepSchemaShape: true}
+		decoded = n.normalizeValue(responseType, coercionSchema, decoded, "$")
+
+		var violations []Violation
+		validateValue(schema, decoded, "$", &violations)
+		if len(violations) > 0 {
+			return *new(T), report, &ValidationError{Violations: violations}
+		}
+
+		options.IgnoreAliases = true
+		coercionSchema = nil
+	}
+
+	n := &normalizer{options: options, report: report}
+	normalized := n.normalizeValue(responseType, coercionSchema, decoded, "$")
+	if err := errors.Join(n.errs...); err != nil {
+		return *new(T), report, fmt.Errorf("unable to unmarshal prompt response '%s': %w", responseJson, err)
+	}
+
+	normalizedJson, err := json.Marshal(normalized)
+	if err != nil {
+		return *new(T), report, fmt.Errorf("unable to unmarshal prompt response '%s': %w", responseJson, err)
+	}
+
+	out := new(T)
+	if err := json.Unmarshal(normalizedJson, out); err != nil {
+		return *new(T), report, fmt.Errorf("unable to unmarshal prompt response '%s': %w", responseJson, err)
+	}
+
+	return *out, report, nil
+}
+
+func decodeJSON(data string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+	return decoded, nil
+}
+
+// normalizeValue rewrites a decoded response so that it can be unmarshaled into the given type,
+// e.g. renaming alias keys or converting the key/value entries generated for maps back into objects.
+// The schema is only set when coercing values.
+func (n *normalizer) normalizeValue(currentType reflect.Type, schema *genai.Schema, value any, path string) any {
+	if value == nil {
+		return nil
+	}
+	if schema != nil {
+		value = n.coerceValue(schema, value, path)
+	}
+
+	for currentType.Kind() == reflect.Pointer {
+		currentType = currentType.Elem()
+	}
+	if implementsUnmarshaler(currentType) {
+		return value
+	}
+
+	switch currentType.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+
+		// When unmarshaling by prompt tags the object is rebuilt with the keys encoding/json expects,
+		// so fields whose json and prompt names differ (or swap) are still populated
+		byJsonName := map[string]any{}
+		fields := promptFields(currentType)
+		names := lo.SliceToMap(fields, func(field promptField) (string, bool) { return field.Params.Name, true })
+		for _, field := range fields {
+			fieldPath := path + "." + field.Params.Name
+			if !n.options.IgnoreAliases {
+				n.resolveAlias(object, field.Params, names, fieldPath)
+			}
+
+			fieldValue, ok := object[field.Params.Name]
+			if !ok {
+				continue
+			}
+			fieldValue = n.normalizeValue(field.Field.Type, propertySchema(schema, field.Params.Name), fieldValue, fieldPath)
+			object[field.Params.Name] = fieldValue
+
+			if n.options.ByPromptTags && !n.keepSchemaShape {
+				jsonName, ok := jsonFieldName(field.Field)
+				if !ok {
+					n.errs = append(n.errs, fmt.Errorf("field %s has prompt name '%s' but is excluded from json", field.Field.Name, field.Params.Name))
+					continue
+				}
+				byJsonName[jsonName] = fieldValue
+			}
+		}
+
+		if n.options.ByPromptTags && !n.keepSchemaShape {
+			return byJsonName
+		}
+		return object
+
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return value
+		}
+
+		for i, item := range items {
+			items[i] = n.normalizeValue(currentType.Elem(), itemsSchema(schema), item, fmt.Sprintf("%s[%d]", path, i))
+		}
+		return items
+
+	case reflect.Map:
+		switch entries := value.(type) {
+		case map[string]any:
+			for key, entryValue := range entries {
+				entries[key] = n.normalizeValue(currentType.Elem(), propertySchema(itemsSchema(schema), mapEntryValue), entryValue, path+"."+key)
+			}
+			return entries
+
+		case []any:
+			if n.keepSchemaShape {
+				for _, entry := range entries {
+					if entryObject, ok := entry.(map[string]any); ok {
+						key, _ := entryObject[mapEntryKey].(string)
+						if entryValue, ok := entryObject[mapEntryValue]; ok {
+							entryObject[mapEntryValue] = n.normalizeValue(currentType.Elem(), propertySchema(itemsSchema(schema), mapEntryValue), entryValue, path+"."+key)
+						}
+					}
+				}
+				return entries
+			}
+
+			object := make(map[string]any, len(entries))
+			for _, entry := range entries {
+				entryObject, ok := entry.(map[string]any)
+				if !ok {
+					return value
+				}
+				key, ok := entryObject[mapEntryKey].(string)
+				if !ok && n.options.partial {
+					continue
+				}
+				if !ok {
+					return value
+				}
+				object[key] = n.normalizeValue(currentType.Elem(), propertySchema(itemsSchema(schema), mapEntryValue), entryObject[mapEntryValue], path+"."+key)
+			}
+			return object
+		}
+	}
+
+	return value
+}
+
+// resolveAlias moves the value of the first alias key found onto the canonical key, unless the canonical key is present.
+// Aliases that are the canonical name of another field are skipped so that field keeps its value.
+func (n *normalizer) resolveAlias(object map[string]any, fieldParams *FieldParams, names map[string]bool, path string) {
+	if _, ok := object[fieldParams.Name]; ok {
+		return
+	}
+
+	for _, alias := range fieldParams.Aliases {
+		if names[alias] {
+			continue
+		}
+		if aliasValue, ok := object[alias]; ok {
+			object[fieldParams.Name] = aliasValue
+			delete(object, alias)
+			n.report.Aliases = append(n.report.Aliases, AliasMatch{Path: path, Alias: alias})
+			return
+		}
+	}
+}
+
+// jsonFieldName returns the key encoding/json uses for a field, false if the field is skipped by encoding/json
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	jsonTag, hasTag := field.Tag.Lookup("json")
+	if jsonTag == "-" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(jsonTag, ",")
+	if !hasTag || name == "" {
+		return field.Name, true
+	}
+	return name, true
+}
+
+func implementsUnmarshaler(t reflect.Type) bool {
+	pointerType := reflect.PointerTo(t)
+	return pointerType.Implements(jsonUnmarshalerType) || pointerType.Implements(textUnmarshalerType)
+}
+
+// promptFields lists the prompt tagged fields of a struct, including those promoted from embedded structs.
+// As with reflect.VisibleFields, the index of a promoted field is its full path from the outer struct.
+func promptFields(structType reflect.Type) []promptField {
+	return collectPromptFields(structType, nil, map[reflect.Type]bool{})
+}
+
+func collectPromptFields(structType reflect.Type, index []int, visited map[reflect.Type]bool) []promptField {
+	if visited[structType] {
+		return nil
+	}
+	visited[structType] = true
+
+	var fields []promptField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				fields = append(fields, collectPromptFields(embeddedType, append(slices.Clone(index), i), visited)...)
+			}
+			continue
+		}
+
+		fieldParams, err := parseFieldParams(field.Tag)
+		if err != nil || fieldParams == nil {
+			continue
+		}
+		field.Index = append(slices.Clone(index), i)
+		fields = append(fields, promptField{Field: field, Params: fieldParams})
+	}
+	return fields
+}
+
+func propertySchema(schema *genai.Schema, name string) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+	return schema.Properties[name]
+}
+
+func itemsSchema(schema *genai.Schema) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+	return schema.Items
+}
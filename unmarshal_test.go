@@ -0,0 +1,258 @@
+package prompterizer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"github.com/tenkeylabs/prompterizer"
+)
+
+type LineItem struct {
+	Description string            `json:"description" prompt:"description,string"`
+	Amount      decimal.Decimal   `json:"amount" prompt:"amount,number"`
+	Attributes  map[string]string `json:"attributes" prompt:"attributes,string"`
+}
+
+type Invoice struct {
+	Number    string              `json:"number" prompt:"number,string,required"`
+	LineItems []LineItem          `json:"lineItems" prompt:"lineItems,object"`
+	Totals    map[string]*float64 `json:"totals" prompt:"totals,number"`
+	Embedded
+}
+
+type Contact struct {
+	FirstName string          `json:"firstName" prompt:"firstName,string" prompt_aliases:"givenName"`
+	LastName  string          `json:"lastName" prompt:"lastName,string" prompt_aliases:"surName, familyName"`
+	Emails    []ContactMethod `json:"emails" prompt:"emails,object" prompt_aliases:"emailAddresses"`
+}
+
+type ContactMethod struct {
+	Value string `json:"value" prompt:"value,string" prompt_aliases:"address"`
+}
+
+type CollidingAlias struct {
+	X string `json:"x" prompt:"x,string" prompt_aliases:"y"`
+	Y string `json:"y" prompt:"y,string"`
+}
+
+type Swapped struct {
+	First  string `json:"second" prompt:"first,string"`
+	Second string `json:"first" prompt:"second,string"`
+}
+
+var _ = Describe("Unmarshal", func() {
+	It("should unmarshal a response", func() {
+		invoice, err := prompterizer.Unmarshal[Invoice](`{"number": "INV-1", "embeddedField": "embedded", "lineItems": [{"description": "Widget", "amount": 10.25}]}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(invoice.Number).To(Equal("INV-1"))
+		Expect(invoice.EmbeddedField).To(Equal("embedded"))
+		Expect(invoice.LineItems).To(HaveLen(1))
+		Expect(invoice.LineItems[0].Description).To(Equal("Widget"))
+		Expect(invoice.LineItems[0].Amount.String()).To(Equal("10.25"))
+	})
+
+	It("should unmarshal map entries into a map", func() {
+		invoice, err := prompterizer.Unmarshal[Invoice](`{
+			"lineItems": [{"attributes": [{"key": "color", "value": "red"}, {"key": "size", "value": "L"}]}],
+			"totals": [{"key": "net", "value": 100.5}, {"key": "tax", "value": null}]
+		}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(invoice.LineItems[0].Attributes).To(Equal(map[string]string{"color": "red", "size": "L"}))
+		Expect(invoice.Totals).To(HaveLen(2))
+		Expect(*invoice.Totals["net"]).To(Equal(100.5))
+		Expect(invoice.Totals["tax"]).To(BeNil())
+	})
+
+	It("should unmarshal a map returned as an object", func() {
+		invoice, err := prompterizer.Unmarshal[Invoice](`{"lineItems": [{"attributes": {"color": "red"}}]}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(invoice.LineItems[0].Attributes).To(Equal(map[string]string{"color": "red"}))
+	})
+
+	It("should unmarshal a slice response", func() {
+		lineItems, err := prompterizer.Unmarshal[[]LineItem](`[{"description": "Widget", "attributes": [{"key": "color", "value": "red"}]}]`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lineItems).To(HaveLen(1))
+		Expect(lineItems[0].Attributes).To(HaveKeyWithValue("color", "red"))
+	})
+
+	It("should unmarshal a recursive type", func() {
+		node, err := prompterizer.Unmarshal[OutlineNode](`{"title": "Root", "children": [{"title": "Child", "children": [{"title": "Grandchild"}]}]}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(node.Children[0].Title).To(Equal("Child"))
+		Expect(node.Children[0].Children[0].Title).To(Equal("Grandchild"))
+	})
+
+	It("should unmarshal a recursive embedded type", func() {
+		value, err := prompterizer.Unmarshal[RecursiveEmbedded](`{"name": "value"}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value.Name).To(Equal("value"))
+	})
+
+	It("should return an error for malformed map entries", func() {
+		_, err := prompterizer.Unmarshal[Invoice](`{"lineItems": [{"attributes": [{"name": "color"}]}]}`)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to unmarshal prompt response"))
+	})
+
+	It("should return an error for invalid JSON", func() {
+		_, err := prompterizer.Unmarshal[Invoice](`{"number": `)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`unable to unmarshal prompt response '{"number": '`))
+	})
+
+	It("should return an error for data after the JSON value", func() {
+		_, err := prompterizer.Unmarshal[Invoice](`{"number": "INV-1"} trailing`)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unexpected data after top-level value"))
+	})
+})
+
+var _ = Describe("UnmarshalWithOptions", func() {
+	Context("aliases", func() {
+		It("should map alias keys onto the canonical fields and report them", func() {
+			contact, report, err := prompterizer.UnmarshalWithOptions[Contact](`{"givenName": "Ada", "familyName": "Lovelace", "emailAddresses": [{"address": "ada@example.com"}]}`, prompterizer.UnmarshalOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contact.FirstName).To(Equal("Ada"))
+			Expect(contact.LastName).To(Equal("Lovelace"))
+			Expect(contact.Emails).To(HaveLen(1))
+			Expect(contact.Emails[0].Value).To(Equal("ada@example.com"))
+			Expect(report.Aliases).To(ConsistOf(
+				prompterizer.AliasMatch{Path: "$.firstName", Alias: "givenName"},
+				prompterizer.AliasMatch{Path: "$.lastName", Alias: "familyName"},
+				prompterizer.AliasMatch{Path: "$.emails", Alias: "emailAddresses"},
+				prompterizer.AliasMatch{Path: "$.emails[0].value", Alias: "address"},
+			))
+		})
+
+		It("should prefer the canonical key over an alias", func() {
+			contact, report, err := prompterizer.UnmarshalWithOptions[Contact](`{"lastName": "Lovelace", "surName": "Byron"}`, prompterizer.UnmarshalOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contact.LastName).To(Equal("Lovelace"))
+			Expect(report.Aliases).To(BeEmpty())
+		})
+
+		It("should use the first alias listed when several are present", func() {
+			contact, report, err := prompterizer.UnmarshalWithOptions[Contact](`{"familyName": "Byron", "surName": "Lovelace"}`, prompterizer.UnmarshalOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contact.LastName).To(Equal("Lovelace"))
+			Expect(report.Aliases).To(ConsistOf(prompterizer.AliasMatch{Path: "$.lastName", Alias: "surName"}))
+		})
+
+		It("should ignore aliases when disabled", func() {
+			contact, report, err := prompterizer.UnmarshalWithOptions[Contact](`{"givenName": "Ada"}`, prompterizer.UnmarshalOptions{IgnoreAliases: true})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contact.FirstName).To(BeEmpty())
+			Expect(report.Aliases).To(BeEmpty())
+		})
+
+		It("should not take the value of another field whose name is an alias", func() {
+			value, report, err := prompterizer.UnmarshalWithOptions[CollidingAlias](`{"y": "yy"}`, prompterizer.UnmarshalOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(CollidingAlias{Y: "yy"}))
+			Expect(report.Aliases).To(BeEmpty())
+		})
+
+		It("should accept aliases in Unmarshal", func() {
+			contact, err := prompterizer.Unmarshal[Contact](`{"givenName": "Ada"}`)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contact.FirstName).To(Equal("Ada"))
+		})
+	})
+
+	Context("by prompt tags", func() {
+		It("should populate fields by their prompt names", func() {
+			value, _, err := prompterizer.UnmarshalWithOptions[Mislabeled](`{"name": "Ada", "untagged": "value", "children": [{"label": "child"}], "other": "ignored"}`, prompterizer.UnmarshalOptions{ByPromptTags: true})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value.Name).To(Equal("Ada"))
+			Expect(value.Untagged).To(Equal("value"))
+			Expect(value.Children).To(HaveLen(1))
+			Expect(value.Children[0].Label).To(Equal("child"))
+			Expect(value.Ignored).To(BeEmpty())
+		})
+
+		It("should not populate fields whose json names differ by default", func() {
+			value, err := prompterizer.Unmarshal[Mislabeled](`{"name": "Ada", "children": [{"label": "child"}]}`)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value.Name).To(BeEmpty())
+			Expect(value.Children[0].Label).To(BeEmpty())
+		})
+
+		It("should handle json and prompt names that are swapped", func() {
+			value, _, err := prompterizer.UnmarshalWithOptions[Swapped](`{"first": "1", "second": "2"}`, prompterizer.UnmarshalOptions{ByPromptTags: true})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value.First).To(Equal("1"))
+			Expect(value.Second).To(Equal("2"))
+		})
+
+		It("should resolve aliases and map entries", func() {
+			contact, report, err := prompterizer.UnmarshalWithOptions[Contact](`{"givenName": "Ada"}`, prompterizer.UnmarshalOptions{ByPromptTags: true})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contact.FirstName).To(Equal("Ada"))
+			Expect(report.Aliases).To(HaveLen(1))
+
+			invoice, _, err := prompterizer.UnmarshalWithOptions[Invoice](`{"embeddedField": "embedded", "lineItems": [{"attributes": [{"key": "color", "value": "red"}]}]}`, prompterizer.UnmarshalOptions{ByPromptTags: true})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(invoice.EmbeddedField).To(Equal("embedded"))
+			Expect(invoice.LineItems[0].Attributes).To(HaveKeyWithValue("color", "red"))
+		})
+
+		It("should return an error for a prompt field excluded from json", func() {
+			_, _, err := prompterizer.UnmarshalWithOptions[Mislabeled](`{"hidden": "value"}`, prompterizer.UnmarshalOptions{ByPromptTags: true})
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("field Hidden has prompt name 'hidden' but is excluded from json"))
+		})
+	})
+
+	Context("lenient", func() {
+		It("should repair messy output and report the repairs", func() {
+			contact, report, err := prompterizer.UnmarshalWithOptions[Contact]("Here you go:\n```json\n{'firstName': 'Ada', 'emails': [{'value': 'ada@example.com'},],}\n```", prompterizer.UnmarshalOptions{Lenient: true})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contact.FirstName).To(Equal("Ada"))
+			Expect(contact.Emails[0].Value).To(Equal("ada@example.com"))
+			Expect(lo.Map(report.Repairs, func(repair prompterizer.Repair, _ int) prompterizer.RepairKind { return repair.Kind })).To(ConsistOf(
+				prompterizer.RepairSurroundingText,
+				prompterizer.RepairCodeFence,
+				prompterizer.RepairSingleQuotes,
+				prompterizer.RepairTrailingCommas,
+			))
+		})
+
+		It("should not repair output by default", func() {
+			_, _, err := prompterizer.UnmarshalWithOptions[Contact]("```json\n{\"firstName\": \"Ada\"}\n```", prompterizer.UnmarshalOptions{})
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error if no payload can be extracted", func() {
+			_, report, err := prompterizer.UnmarshalWithOptions[Contact]("No contact found.", prompterizer.UnmarshalOptions{Lenient: true})
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no JSON object or array found in response"))
+			Expect(report).ToNot(BeNil())
+		})
+	})
+})
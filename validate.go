@@ -0,0 +1,181 @@
+package prompterizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samber/lo"
+	"google.golang.org/genai"
+)
+
+type Violation struct {
+	Path    string
+	Message string
+}
+
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	violations := lo.Map(e.Violations, func(violation Violation, _ int) string {
+		return fmt.Sprintf("%s: %s", violation.Path, violation.Message)
+	})
+	return fmt.Sprintf("response does not match schema: %s", strings.Join(violations, "; "))
+}
+
+func UnmarshalStrict[T any](responseJson string, templateVariables map[string]string) (T, error) {
+	schema, err := MarshalResponseSchema(new(T), templateVariables)
+	if err != nil {
+		return *new(T), fmt.Errorf("unable to generate schema for validation: %w", err)
+	}
+
+	// Aliases are resolved before validating, as they are by Unmarshal and the generator
+	out, _, err := decodeResponse[T](responseJson, UnmarshalOptions{TemplateVariables: templateVariables}, schema)
+	return out, err
+}
+
+// Validate checks a JSON response against a schema generated by MarshalResponseSchema. Violations are
+// reported with JSON paths rooted at '$', an error is only returned if the response is not valid JSON.
+func Validate(schema *genai.Schema, responseJson string) ([]Violation, error) {
+	decoded, err := decodeJSON(responseJson)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	validateValue(schema, decoded, "$", &violations)
+	return violations, nil
+}
+
+func validateValue(schema *genai.Schema, value any, path string, violations *[]Violation) {
+	addViolation := func(format string, args ...any) {
+		*violations = append(*violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		if !lo.FromPtr(schema.Nullable) {
+			addViolation("value is null but the field is not nullable")
+		}
+		return
+	}
+
+	switch schema.Type {
+	case genai.TypeObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			addViolation("expected object, got %s", jsonTypeName(value))
+			return
+		}
+
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				*violations = append(*violations, Violation{Path: path + "." + name, Message: "required property is missing"})
+			}
+		}
+
+		names := lo.Keys(schema.Properties)
+		slices.Sort(names)
+		for _, name := range names {
+			if propertyValue, ok := object[name]; ok {
+				validateValue(schema.Properties[name], propertyValue, path+"."+name, violations)
+			}
+		}
+
+	case genai.TypeArray:
+		items, ok := value.([]any)
+		if !ok {
+			addViolation("expected array, got %s", jsonTypeName(value))
+			return
+		}
+
+		if schema.MinItems != nil && int64(len(items)) < *schema.MinItems {
+			addViolation("expected at least %d items, got %d", *schema.MinItems, len(items))
+		}
+		if schema.MaxItems != nil && int64(len(items)) > *schema.MaxItems {
+			addViolation("expected at most %d items, got %d", *schema.MaxItems, len(items))
+		}
+		for i, item := range items {
+			validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+		}
+
+	case genai.TypeString:
+		text, ok := value.(string)
+		if !ok {
+			addViolation("expected string, got %s", jsonTypeName(value))
+			return
+		}
+
+		length := int64(utf8.RuneCountInString(text))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			addViolation("expected at least %d characters, got %d", *schema.MinLength, length)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			addViolation("expected at most %d characters, got %d", *schema.MaxLength, length)
+		}
+		if schema.Pattern != "" {
+			if pattern, err := regexp.Compile(schema.Pattern); err == nil && !pattern.MatchString(text) {
+				addViolation("value '%s' does not match pattern '%s'", text, schema.Pattern)
+			}
+		}
+		validateEnum(schema, text, addViolation)
+
+	case genai.TypeNumber, genai.TypeInteger:
+		number, ok := value.(json.Number)
+		if !ok {
+			addViolation("expected %s, got %s", strings.ToLower(string(schema.Type)), jsonTypeName(value))
+			return
+		}
+
+		parsed, err := number.Float64()
+		if err != nil {
+			addViolation("invalid number %s", number)
+			return
+		}
+		if schema.Type == genai.TypeInteger && parsed != math.Trunc(parsed) {
+			addViolation("expected integer, got %s", number)
+		}
+		if schema.Minimum != nil && parsed < *schema.Minimum {
+			addViolation("value %s is less than the minimum %v", number, *schema.Minimum)
+		}
+		if schema.Maximum != nil && parsed > *schema.Maximum {
+			addViolation("value %s is greater than the maximum %v", number, *schema.Maximum)
+		}
+		validateEnum(schema, number.String(), addViolation)
+
+	case genai.TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			addViolation("expected boolean, got %s", jsonTypeName(value))
+		}
+	}
+}
+
+func validateEnum(schema *genai.Schema, value string, addViolation func(format string, args ...any)) {
+	if len(schema.Enum) > 0 && !lo.Contains(schema.Enum, value) {
+		addViolation("value '%s' is not one of %s", value, strings.Join(schema.Enum, ", "))
+	}
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,157 @@
+package prompterizer_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tenkeylabs/prompterizer"
+	"google.golang.org/genai"
+)
+
+type Shipment struct {
+	ID      string   `json:"id" prompt:"id,string,required" prompt_pattern:"^SH-[0-9]+$"`
+	Status  string   `json:"status" prompt:"status,string,required" prompt_enum:"{statuses}"`
+	Weight  *float64 `json:"weight" prompt:"weight,number" prompt_min:"0"`
+	Pieces  int      `json:"pieces" prompt:"pieces,integer" prompt_min:"1" prompt_max:"10"`
+	Fragile bool     `json:"fragile" prompt:"fragile,bool"`
+	Tags    []string `json:"tags" prompt:"tags,string" prompt_max_items:"2" prompt_max_length:"5"`
+	Events  []Event  `json:"events" prompt:"events,object"`
+}
+
+var _ = Describe("Validate", func() {
+	var (
+		schema            *genai.Schema
+		templateVariables map[string]string
+	)
+
+	BeforeEach(func() {
+		var err error
+		templateVariables = map[string]string{"statuses": "pending,shipped,delivered"}
+		schema, err = prompterizer.MarshalResponseSchema(Shipment{}, templateVariables)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should return no violations for a valid response", func() {
+		violations, err := prompterizer.Validate(schema, `{"id": "SH-1", "status": "shipped", "weight": null, "pieces": 2, "fragile": true, "tags": ["a", "b"], "events": [{"name": "Picked up"}]}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(BeEmpty())
+	})
+
+	It("should ignore properties that are not in the schema", func() {
+		violations, err := prompterizer.Validate(schema, `{"id": "SH-1", "status": "shipped", "carrier": "UPS"}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(BeEmpty())
+	})
+
+	It("should report missing required properties", func() {
+		violations, err := prompterizer.Validate(schema, `{"pieces": 1}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(ConsistOf(
+			prompterizer.Violation{Path: "$.id", Message: "required property is missing"},
+			prompterizer.Violation{Path: "$.status", Message: "required property is missing"},
+		))
+	})
+
+	It("should report values outside of the enum", func() {
+		violations, err := prompterizer.Validate(schema, `{"id": "SH-1", "status": "lost"}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(ConsistOf(
+			prompterizer.Violation{Path: "$.status", Message: "value 'lost' is not one of pending, shipped, delivered"},
+		))
+	})
+
+	It("should report null values for fields that are not nullable", func() {
+		violations, err := prompterizer.Validate(schema, `{"id": "SH-1", "status": "shipped", "pieces": null, "events": [{"name": null}]}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(ConsistOf(
+			prompterizer.Violation{Path: "$.pieces", Message: "value is null but the field is not nullable"},
+			prompterizer.Violation{Path: "$.events[0].name", Message: "value is null but the field is not nullable"},
+		))
+	})
+
+	It("should report type mismatches", func() {
+		violations, err := prompterizer.Validate(schema, `{"id": 1, "status": "shipped", "pieces": 1.5, "fragile": "yes", "tags": "a", "events": {}}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(ConsistOf(
+			prompterizer.Violation{Path: "$.id", Message: "expected string, got number"},
+			prompterizer.Violation{Path: "$.pieces", Message: "expected integer, got 1.5"},
+			prompterizer.Violation{Path: "$.fragile", Message: "expected boolean, got string"},
+			prompterizer.Violation{Path: "$.tags", Message: "expected array, got string"},
+			prompterizer.Violation{Path: "$.events", Message: "expected array, got object"},
+		))
+	})
+
+	It("should report constraint violations", func() {
+		violations, err := prompterizer.Validate(schema, `{"id": "PKG-1", "status": "shipped", "weight": -1, "pieces": 11, "tags": ["a", "b", "longer"]}`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(ConsistOf(
+			prompterizer.Violation{Path: "$.id", Message: "value 'PKG-1' does not match pattern '^SH-[0-9]+$'"},
+			prompterizer.Violation{Path: "$.weight", Message: "value -1 is less than the minimum 0"},
+			prompterizer.Violation{Path: "$.pieces", Message: "value 11 is greater than the maximum 10"},
+			prompterizer.Violation{Path: "$.tags", Message: "expected at most 2 items, got 3"},
+			prompterizer.Violation{Path: "$.tags[2]", Message: "expected at most 5 characters, got 6"},
+		))
+	})
+
+	It("should return an error for invalid JSON", func() {
+		_, err := prompterizer.Validate(schema, `{"id": `)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+type Signatory struct {
+	LastName string `json:"lastName" prompt:"lastName,string,required" prompt_aliases:"surName"`
+}
+
+var _ = Describe("UnmarshalStrict", func() {
+	templateVariables := map[string]string{"statuses": "pending,shipped,delivered"}
+
+	It("should unmarshal a valid response", func() {
+		shipment, err := prompterizer.UnmarshalStrict[Shipment](`{"id": "SH-1", "status": "pending", "weight": 2.5}`, templateVariables)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(shipment.ID).To(Equal("SH-1"))
+		Expect(*shipment.Weight).To(Equal(2.5))
+	})
+
+	It("should resolve aliases before validating", func() {
+		signatory, err := prompterizer.UnmarshalStrict[Signatory](`{"surName": "Lovelace"}`, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signatory.LastName).To(Equal("Lovelace"))
+	})
+
+	It("should return a validation error with every violation", func() {
+		_, err := prompterizer.UnmarshalStrict[Shipment](`{"status": "lost"}`, templateVariables)
+
+		Expect(err).To(HaveOccurred())
+
+		var validationErr *prompterizer.ValidationError
+		Expect(errors.As(err, &validationErr)).To(BeTrue())
+		Expect(validationErr.Violations).To(HaveLen(2))
+		Expect(err.Error()).To(Equal("response does not match schema: $.id: required property is missing; $.status: value 'lost' is not one of pending, shipped, delivered"))
+	})
+
+	It("should return an error if the schema cannot be generated", func() {
+		_, err := prompterizer.UnmarshalStrict[Shipment](`{}`, map[string]string{})
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to generate schema for validation: error rendering enum for status: missing variable in enum: statuses"))
+	})
+
+	It("should return an error for invalid JSON", func() {
+		_, err := prompterizer.UnmarshalStrict[Shipment](`not json`, templateVariables)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to unmarshal prompt response 'not json'"))
+	})
+})